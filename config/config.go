@@ -0,0 +1,108 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"gopkg.in/yaml.v3"
+	"io"
+	"lopper/git"
+	"os"
+	"path/filepath"
+)
+
+// Config is the configuration of lopper loaded from the config file.
+type Config struct {
+	Theme Theme `yaml:"theme"`
+	// Keys rebind the actions of the TUI (e.g. "page-down") to the given keys.
+	Keys  map[string][]string `yaml:"keys"`
+	Hooks Hooks               `yaml:"hooks"`
+	// Providers are the hosting providers whose API is asked about the pull requests of the branches.
+	Providers []Provider `yaml:"providers"`
+	// Trunks are the branches the branches of the matching repositories are merged into, for repositories with more
+	// than one (e.g. develop and main). The first matching entry is used.
+	Trunks []Trunk `yaml:"trunks"`
+}
+
+// Trunk are the trunk branches of the repositories whose names match a pattern.
+type Trunk struct {
+	// Repositories is the pattern the names of the repositories are matched against (e.g. release-*), like
+	// filepath.Match.
+	Repositories string `yaml:"repositories"`
+	// Branches are the branches the branches are merged into (e.g. develop and main).
+	Branches []string `yaml:"branches"`
+}
+
+// TrunkNames returns the names of the trunk branches of the given repository, and whether all of them are trunks.
+// Unless trunk branches have been configured or declared for the repository, only the first existing of main and
+// master is.
+func TrunkNames(trunks []Trunk, repo git.Repository) ([]string, bool) {
+	for _, t := range trunks {
+		if matched, _ := filepath.Match(t.Repositories, repo.Name); matched {
+			return t.Branches, true
+		}
+	}
+	if len(repo.Trunk) > 0 {
+		return []string{repo.Trunk}, true
+	}
+	return []string{"main", "master"}, false
+}
+
+// Provider is a hosting provider of Git repositories.
+type Provider struct {
+	// Type is the type of the provider (github, gitlab or gitea).
+	Type string `yaml:"type"`
+	// Host is the host of the remote URLs of the repositories hosted by the provider (e.g. github.com).
+	Host string `yaml:"host"`
+	// URL is the base URL of the API. It defaults to the API of the provider at the host.
+	URL string `yaml:"url"`
+	// TokenEnv is the name of the environment variable holding the token to authenticate with.
+	TokenEnv string `yaml:"token-env"`
+}
+
+// Hooks are the shell commands run around the deletion of branches. Empty commands are not run.
+type Hooks struct {
+	// PreDelete is run before each branch is deleted. A non-zero exit code skips the branch.
+	PreDelete string `yaml:"pre-delete"`
+	// PostDelete is run after each branch has been deleted.
+	PostDelete string `yaml:"post-delete"`
+	// PostRun is run once all repositories have been processed, with the report of the run.
+	PostRun string `yaml:"post-run"`
+}
+
+// Theme is the theme the UI is rendered with.
+type Theme struct {
+	// Name is the name of the built-in theme the theme is based on.
+	Name string `yaml:"name"`
+	// Colors override the colors of the roles (completed, error, spinner, muted) of the built-in theme.
+	Colors map[string]string `yaml:"colors"`
+	// Symbols override the glyphs of the roles (completed, error, skipped, paused, branch, leaf, divider, spinner) of
+	// the built-in theme.
+	Symbols map[string]string `yaml:"symbols"`
+}
+
+// DefaultPath returns the default path of the config file. An empty string is returned when the config directory of
+// the user cannot be determined.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "lopper", "config.yaml")
+}
+
+// Load loads the Config from the file at the given path. The returned error wraps os.ErrNotExist when the file does
+// not exist.
+func Load(path string) (Config, error) {
+	var c Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	// fail on misspelled keys rather than silently ignoring them
+	decoder.KnownFields(true)
+	if err = decoder.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return c, err
+	}
+	return c, nil
+}
@@ -0,0 +1,155 @@
+package config_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lopper/config"
+	"lopper/git"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expected    config.Config
+		expectedErr bool
+	}{
+		{
+			name: "Theme",
+			content: "theme:\n" +
+				"  name: monochrome\n" +
+				"  colors:\n" +
+				"    error: '#FF00FF'\n" +
+				"  symbols:\n" +
+				"    completed: ok\n",
+			expected: config.Config{
+				Theme: config.Theme{
+					Name:    "monochrome",
+					Colors:  map[string]string{"error": "#FF00FF"},
+					Symbols: map[string]string{"completed": "ok"},
+				},
+			},
+		},
+		{
+			name: "Keys",
+			content: "keys:\n" +
+				"  up: [up, w]\n" +
+				"  down: [down, s]\n",
+			expected: config.Config{
+				Keys: map[string][]string{"up": {"up", "w"}, "down": {"down", "s"}},
+			},
+		},
+		{
+			name: "Hooks",
+			content: "hooks:\n" +
+				"  pre-delete: ./check.sh\n" +
+				"  post-run: ./notify.sh\n",
+			expected: config.Config{
+				Hooks: config.Hooks{PreDelete: "./check.sh", PostRun: "./notify.sh"},
+			},
+		},
+		{
+			name: "Providers",
+			content: "providers:\n" +
+				"  - type: github\n" +
+				"    host: github.com\n" +
+				"    token-env: GITHUB_TOKEN\n" +
+				"  - type: gitlab\n" +
+				"    host: gitlab.example.com\n" +
+				"    url: http://localhost:8080/api/v4\n",
+			expected: config.Config{
+				Providers: []config.Provider{
+					{Type: "github", Host: "github.com", TokenEnv: "GITHUB_TOKEN"},
+					{Type: "gitlab", Host: "gitlab.example.com", URL: "http://localhost:8080/api/v4"},
+				},
+			},
+		},
+		{
+			name: "Trunks",
+			content: "trunks:\n" +
+				"  - repositories: release-*\n" +
+				"    branches: [develop, main]\n",
+			expected: config.Config{
+				Trunks: []config.Trunk{{Repositories: "release-*", Branches: []string{"develop", "main"}}},
+			},
+		},
+		{
+			name:     "Empty",
+			content:  "",
+			expected: config.Config{},
+		},
+		{
+			name:        "Unknown Key",
+			content:     "them:\n  name: monochrome\n",
+			expectedErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(test.content), 0644))
+			actual, err := config.Load(path)
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLoadNotExist(t *testing.T) {
+	_, err := config.Load(filepath.Join(t.TempDir(), "config.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestTrunkNames(t *testing.T) {
+	trunks := []config.Trunk{
+		{Repositories: "release-*", Branches: []string{"develop", "main"}},
+		{Repositories: "*", Branches: []string{"trunk"}},
+	}
+	tests := []struct {
+		name        string
+		trunks      []config.Trunk
+		repo        git.Repository
+		expected    []string
+		expectedAll bool
+	}{
+		{
+			name:        "First Match",
+			trunks:      trunks,
+			repo:        git.Repository{Name: "release-1"},
+			expected:    []string{"develop", "main"},
+			expectedAll: true,
+		},
+		{
+			name:        "Fallback Match",
+			trunks:      trunks,
+			repo:        git.Repository{Name: "project"},
+			expected:    []string{"trunk"},
+			expectedAll: true,
+		},
+		{
+			name:        "Declared",
+			repo:        git.Repository{Name: "project", Trunk: "develop"},
+			expected:    []string{"develop"},
+			expectedAll: true,
+		},
+		{
+			name:     "Default",
+			repo:     git.Repository{Name: "project"},
+			expected: []string{"main", "master"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, all := config.TrunkNames(test.trunks, test.repo)
+			assert.Equal(t, test.expected, actual)
+			assert.Equal(t, test.expectedAll, all)
+		})
+	}
+}
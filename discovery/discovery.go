@@ -0,0 +1,175 @@
+// Package discovery finds the Git repositories to process.
+package discovery
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"lopper/git"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GetRepositories returns the repositories of the given projects of a workspace manifest, followed by the repositories
+// at the given paths, or the repositories in the directories directly under the given paths. Repositories reached
+// through more than one path (e.g. symlinks or worktrees of the same repository) are only returned once. With
+// recurseSubmodules, the initialized submodules of the repositories are returned as well, nested under their parent
+// repository. The submodules of a repository that cannot be listed are left out, and the reason is returned as a
+// warning.
+func GetRepositories(ctx context.Context, paths []string, projects []Project, recurseSubmodules bool) ([]git.Repository, []error, error) {
+	d := discoverer{recurseSubmodules: recurseSubmodules, seen: make(map[string]bool)}
+	for _, project := range projects {
+		// the projects that have not been checked out (e.g. not synced yet) are left out
+		if !git.IsGitRepository(ctx, project.Path) {
+			continue
+		}
+		if err := d.appendRepository(ctx, project); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, path := range paths {
+		// check if the path given is a repository
+		if git.IsGitRepository(ctx, path) {
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				return nil, nil, err
+			}
+			if err = d.appendRepository(ctx, Project{Path: absPath}); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		// else the path is a directory of containing repositories
+		dir, err := os.ReadDir(path)
+		if err != nil {
+			return d.repositories, d.warnings, err
+		}
+		for _, entry := range dir {
+			// only consider directories that are git repositories
+			if entry.IsDir() && git.IsGitRepository(ctx, filepath.Join(path, entry.Name())) {
+				if err = d.appendRepository(ctx, Project{Path: filepath.Join(path, entry.Name())}); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+	return d.repositories, d.warnings, nil
+}
+
+// ReadPaths reads newline-separated paths from the given reader (e.g. the output of find). Blank lines are ignored.
+func ReadPaths(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if path := strings.TrimSpace(scanner.Text()); len(path) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// discoverer collects the repositories while they are discovered.
+type discoverer struct {
+	recurseSubmodules bool
+	repositories      []git.Repository
+	warnings          []error
+	// worktrees of the same repository share a common directory, track them to only process a repository once
+	seen map[string]bool
+}
+
+// markSeen records the repository with the given common directory as seen, and returns false if it has been seen
+// before.
+func (d *discoverer) markSeen(commonDir string) bool {
+	// the same repository can be reached through symlinks, so compare the resolved common directories
+	if resolved, err := filepath.EvalSymlinks(commonDir); err == nil {
+		commonDir = resolved
+	}
+	if d.seen[commonDir] {
+		return false
+	}
+	d.seen[commonDir] = true
+	return true
+}
+
+// appendRepository appends the repository of the given project, unless it has been seen before, followed by its
+// submodules when recurseSubmodules is set.
+func (d *discoverer) appendRepository(ctx context.Context, project Project) error {
+	repository, commonDir, err := newRepository(ctx, project.Path)
+	if err != nil {
+		return err
+	}
+	repository.Trunk = project.Trunk
+	repository.TrunkRemote = project.Remote
+	if !d.markSeen(commonDir) {
+		return nil
+	}
+	d.repositories = append(d.repositories, repository)
+	if d.recurseSubmodules {
+		return d.appendSubmodules(ctx, repository)
+	}
+	return nil
+}
+
+// appendSubmodules appends the initialized submodules of the given repository, and their submodules, directly after
+// the repository so they are nested under it.
+func (d *discoverer) appendSubmodules(ctx context.Context, parent git.Repository) error {
+	// bare repositories do not have a working tree to initialize submodules in
+	if parent.Bare {
+		return nil
+	}
+	parentPath := filepath.Join(parent.Path, parent.Name)
+	submodules, err := git.GetSubmodules(ctx, parentPath)
+	if err != nil {
+		// the repository itself can still be processed
+		d.warnings = append(d.warnings, fmt.Errorf("%s: %w", parentPath, err))
+		return nil
+	}
+	for _, submodule := range submodules {
+		submodulePath := filepath.Join(parentPath, submodule)
+		// a submodule may have been given as a path as well
+		commonDir, err := git.GetCommonDir(ctx, submodulePath)
+		if err != nil {
+			return err
+		}
+		if !d.markSeen(commonDir) {
+			continue
+		}
+		repository := git.Repository{
+			Path:  filepath.Dir(submodulePath),
+			Name:  filepath.Base(submodulePath),
+			Depth: parent.Depth + 1,
+		}
+		d.repositories = append(d.repositories, repository)
+		if err = d.appendSubmodules(ctx, repository); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newRepository creates the git.Repository for the given path and returns it along with the common directory of the
+// repository. When the path is a linked worktree, the repository of the main worktree is returned instead.
+func newRepository(ctx context.Context, path string) (git.Repository, string, error) {
+	commonDir, err := git.GetCommonDir(ctx, path)
+	if err != nil {
+		return git.Repository{}, "", err
+	}
+	worktrees, err := git.GetWorktrees(ctx, path)
+	if err != nil {
+		return git.Repository{}, "", err
+	}
+	// the first worktree is the main worktree, which is the bare repository itself for bare repositories
+	if len(worktrees) > 0 {
+		path = worktrees[0].Path
+	}
+	return git.Repository{
+		Path: filepath.Dir(path),
+		Name: filepath.Base(path),
+		Bare: git.IsBareRepository(ctx, path),
+	}, commonDir, nil
+}
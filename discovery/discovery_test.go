@@ -0,0 +1,43 @@
+package discovery
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestReadPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "Paths",
+			input:    "/repos/foo\n/repos/bar\n",
+			expected: []string{"/repos/foo", "/repos/bar"},
+		},
+		{
+			name:     "Blank Lines",
+			input:    "/repos/foo\n\n  \n/repos/bar",
+			expected: []string{"/repos/foo", "/repos/bar"},
+		},
+		{
+			name:     "Windows Line Endings",
+			input:    "C:\\repos\\foo\r\nC:\\repos\\bar\r\n",
+			expected: []string{"C:\\repos\\foo", "C:\\repos\\bar"},
+		},
+		{
+			name:     "Empty",
+			input:    "",
+			expected: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := ReadPaths(strings.NewReader(test.input))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package discovery
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Project is a repository declared in a workspace manifest.
+type Project struct {
+	// Path is the path of the repository.
+	Path string
+	// Trunk is the declared default branch of the repository. It is empty when the manifest does not declare a branch.
+	Trunk string
+	// Remote is the remote the default branch is tracked from. It is empty when the manifest does not declare a remote.
+	Remote string
+}
+
+// ReadManifest reads the projects of the workspace manifest at the given path. Manifests of the repo tool are read from
+// XML files, and lists of projects from YAML files. The paths of the projects are resolved relative to the workspace,
+// which is the directory containing the .repo directory for the repo tool, and the directory of the manifest otherwise.
+func ReadManifest(path string) ([]Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		return parseRepoManifest(data, getRepoWorkspace(absPath))
+	case ".yaml", ".yml":
+		return parseYAMLManifest(data, filepath.Dir(absPath))
+	default:
+		return nil, fmt.Errorf("unknown manifest format %s, must be .xml, .yaml or .yml", filepath.Ext(path))
+	}
+}
+
+// getRepoWorkspace returns the workspace of the repo tool manifest at the given path. The manifests of the repo tool
+// are kept in the .repo directory (e.g. .repo/manifest.xml or .repo/manifests/default.xml) at the root of the
+// workspace.
+func getRepoWorkspace(path string) string {
+	for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if filepath.Base(dir) == ".repo" {
+			return filepath.Dir(dir)
+		}
+	}
+	return filepath.Dir(path)
+}
+
+// repoManifest is the manifest of the repo tool. See https://gerrit.googlesource.com/git-repo/+/HEAD/docs/manifest-format.md.
+type repoManifest struct {
+	Remotes []struct {
+		Name     string `xml:"name,attr"`
+		Revision string `xml:"revision,attr"`
+	} `xml:"remote"`
+	Default struct {
+		Remote   string `xml:"remote,attr"`
+		Revision string `xml:"revision,attr"`
+	} `xml:"default"`
+	Projects []struct {
+		Name     string `xml:"name,attr"`
+		Path     string `xml:"path,attr"`
+		Remote   string `xml:"remote,attr"`
+		Revision string `xml:"revision,attr"`
+	} `xml:"project"`
+}
+
+func parseRepoManifest(data []byte, workspace string) ([]Project, error) {
+	var manifest repoManifest
+	if err := xml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	remoteRevisions := make(map[string]string)
+	for _, remote := range manifest.Remotes {
+		remoteRevisions[remote.Name] = remote.Revision
+	}
+	projects := make([]Project, 0, len(manifest.Projects))
+	for _, p := range manifest.Projects {
+		// the path defaults to the name of the project
+		path := p.Path
+		if len(path) == 0 {
+			path = p.Name
+		}
+		// the revision is inherited from the remote of the project, and then from the default
+		remote := p.Remote
+		if len(remote) == 0 {
+			remote = manifest.Default.Remote
+		}
+		revision := p.Revision
+		if len(revision) == 0 {
+			revision = remoteRevisions[remote]
+		}
+		if len(revision) == 0 {
+			revision = manifest.Default.Revision
+		}
+		// the repo tool names the remote of the checkout after the remote of the manifest
+		project := Project{Path: filepath.Join(workspace, path), Trunk: getBranch(revision)}
+		if len(project.Trunk) > 0 {
+			project.Remote = remote
+		}
+		projects = append(projects, project)
+	}
+	return projects, nil
+}
+
+// shaPattern matches the revisions that are commits rather than branches.
+var shaPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// getBranch returns the branch of the given revision of the repo tool. An empty string is returned when the revision is
+// not a branch (e.g. a tag or a commit).
+func getBranch(revision string) string {
+	if strings.HasPrefix(revision, "refs/heads/") {
+		return strings.TrimPrefix(revision, "refs/heads/")
+	}
+	if strings.HasPrefix(revision, "refs/") || shaPattern.MatchString(revision) {
+		return ""
+	}
+	return revision
+}
+
+// yamlProject is a project of a YAML manifest.
+type yamlProject struct {
+	Path          string `yaml:"path"`
+	DefaultBranch string `yaml:"default-branch"`
+}
+
+func parseYAMLManifest(data []byte, workspace string) ([]Project, error) {
+	var manifest []yamlProject
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	// fail on misspelled keys rather than silently ignoring them
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&manifest); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	projects := make([]Project, 0, len(manifest))
+	for i, p := range manifest {
+		if len(p.Path) == 0 {
+			return nil, fmt.Errorf("project %d of the manifest does not have a path", i+1)
+		}
+		path := p.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workspace, path)
+		}
+		projects = append(projects, Project{Path: path, Trunk: p.DefaultBranch})
+	}
+	return projects, nil
+}
@@ -0,0 +1,96 @@
+package discovery
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadManifest(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		expected    []Project
+		expectedErr bool
+	}{
+		{
+			name: "Repo Tool",
+			file: filepath.Join(".repo", "manifests", "default.xml"),
+			content: `<?xml version="1.0" encoding="UTF-8"?>
+<manifest>
+  <remote name="origin" fetch=".." />
+  <remote name="legacy" fetch=".." revision="master" />
+  <default remote="origin" revision="main" />
+  <project name="platform/build" path="build" revision="refs/heads/develop" />
+  <project name="platform/tools" />
+  <project name="platform/legacy" path="legacy" remote="legacy" />
+  <project name="platform/pinned" path="pinned" revision="0123456789abcdef0123456789abcdef01234567" />
+  <project name="platform/tagged" path="tagged" revision="refs/tags/v1.0.0" />
+</manifest>`,
+			expected: []Project{
+				{Path: "build", Trunk: "develop", Remote: "origin"},
+				{Path: filepath.Join("platform", "tools"), Trunk: "main", Remote: "origin"},
+				{Path: "legacy", Trunk: "master", Remote: "legacy"},
+				{Path: "pinned"},
+				{Path: "tagged"},
+			},
+		},
+		{
+			name: "Repo Tool Outside Workspace",
+			file: "manifest.xml",
+			content: `<manifest>
+  <project name="build" />
+</manifest>`,
+			expected: []Project{{Path: "build"}},
+		},
+		{
+			name: "YAML",
+			file: "workspace.yaml",
+			content: "- path: api\n" +
+				"  default-branch: develop\n" +
+				"- path: web\n",
+			expected: []Project{{Path: "api", Trunk: "develop"}, {Path: "web"}},
+		},
+		{
+			name:        "YAML Without Path",
+			file:        "workspace.yml",
+			content:     "- default-branch: develop\n",
+			expectedErr: true,
+		},
+		{
+			name:        "YAML Unknown Key",
+			file:        "workspace.yml",
+			content:     "- path: api\n  branch: develop\n",
+			expectedErr: true,
+		},
+		{
+			name:        "Unknown Format",
+			file:        "workspace.json",
+			content:     "[]",
+			expectedErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := filepath.EvalSymlinks(t.TempDir())
+			require.NoError(t, err)
+			path := filepath.Join(dir, test.file)
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			require.NoError(t, os.WriteFile(path, []byte(test.content), 0644))
+			actual, err := ReadManifest(path)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			// the paths are resolved relative to the workspace, which is the temporary directory
+			for i := range test.expected {
+				test.expected[i].Path = filepath.Join(dir, test.expected[i].Path)
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"lopper/config"
+	"lopper/discovery"
+	"lopper/doctor"
+	"os"
+)
+
+// doctorCommand diagnoses the repositories that lopper cannot process, without modifying anything.
+func doctorCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "doctor",
+		Usage: "diagnoses problems that prevent repositories from being processed, without modifying anything",
+		Flags: append(pathFlags(),
+			&cli.BoolFlag{
+				Name:  "recurse-submodules",
+				Usage: "diagnoses the initialized submodules of each repository",
+			},
+			&cli.StringFlag{
+				Name:  "trunk-remote",
+				Usage: "checks that the given remote has the trunks the branches are compared against",
+			},
+			&cli.StringFlag{
+				Name:  "prune-remote",
+				Usage: "checks that the given remote the merged branches are deleted on exists",
+			},
+			configFlag(),
+		),
+		Action: func(ctx *cli.Context) error {
+			found := false
+			version, findings := doctor.CheckGit(ctx.Context)
+			printFindings(fmt.Sprintf("git %s", version), findings)
+			found = found || len(findings) > 0
+			c, err := loadConfig(ctx)
+			if err != nil {
+				return err
+			}
+			if err = checkTrunks(c.Trunks); err != nil {
+				return err
+			}
+			paths, err := getPaths(ctx)
+			if err != nil {
+				return err
+			}
+			projects, err := getProjects(ctx)
+			if err != nil {
+				return err
+			}
+			repositories, warnings, err := discovery.GetRepositories(ctx.Context, paths, projects, ctx.Bool("recurse-submodules"))
+			if err != nil {
+				return err
+			}
+			for _, warning := range warnings {
+				fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+			}
+			if len(repositories) == 0 {
+				fmt.Println("There are no repositories in this directory.")
+			}
+			for _, repository := range repositories {
+				names, all := config.TrunkNames(c.Trunks, repository)
+				findings = doctor.CheckRepository(ctx.Context, repository, doctor.Options{
+					Trunks:      names,
+					AllTrunks:   all,
+					TrunkRemote: ctx.String("trunk-remote"),
+					PruneRemote: ctx.String("prune-remote"),
+				})
+				printFindings(repository.Name, findings)
+				found = found || len(findings) > 0
+			}
+			if found {
+				return cli.Exit("", exitFailures)
+			}
+			return nil
+		},
+	}
+}
+
+func printFindings(name string, findings []doctor.Finding) {
+	if len(findings) == 0 {
+		fmt.Printf("%s: ok\n", name)
+		return
+	}
+	fmt.Printf("%s:\n", name)
+	for _, finding := range findings {
+		fmt.Printf("   problem: %s\n", finding.Problem)
+		fmt.Printf("   fix:     %s\n", finding.Fix)
+	}
+}
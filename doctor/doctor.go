@@ -0,0 +1,190 @@
+// Package doctor diagnoses the problems that prevent lopper from processing repositories, without modifying them.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"lopper/git"
+	"lopper/utils"
+	"path/filepath"
+)
+
+// MinGitVersion is the oldest version of Git supporting all commands run by lopper (e.g. git worktree remove).
+const MinGitVersion = "2.17.0"
+
+// Finding is a problem found by the doctor, with how to fix it.
+type Finding struct {
+	Problem string
+	Fix     string
+}
+
+// CheckGit returns the version of Git and the problems found with it.
+func CheckGit(ctx context.Context) (string, []Finding) {
+	version, err := git.GetVersion(ctx)
+	if err != nil {
+		return "", []Finding{{
+			Problem: fmt.Sprintf("git cannot be run: %s", err),
+			Fix:     "install Git and make sure it is on the PATH",
+		}}
+	}
+	return version, checkVersion(version)
+}
+
+// checkVersion returns the problems found with the given version of Git.
+func checkVersion(version string) []Finding {
+	if git.CompareVersions(version, MinGitVersion) < 0 {
+		return []Finding{{
+			Problem: fmt.Sprintf("git %s is older than the supported %s", version, MinGitVersion),
+			Fix:     fmt.Sprintf("upgrade Git to %s or newer", MinGitVersion),
+		}}
+	}
+	return nil
+}
+
+// Options are the settings of the run the repositories are checked for.
+type Options struct {
+	// Trunks are the names of the trunk branches of the repository (e.g. main).
+	Trunks []string
+	// AllTrunks is set when all the Trunks are trunks, instead of only the first that exists.
+	AllTrunks bool
+	// TrunkRemote is the remote the branches are compared against, if any.
+	TrunkRemote string
+	// PruneRemote is the remote the merged branches are deleted on as well, if any.
+	PruneRemote string
+}
+
+// CheckRepository returns the problems found with the given repository when it is processed with the given options.
+func CheckRepository(ctx context.Context, repo git.Repository, options Options) []Finding {
+	path := filepath.Join(repo.Path, repo.Name)
+	var findings []Finding
+	remotes, err := git.GetRemotes(ctx, path)
+	if err != nil {
+		findings = append(findings, Finding{Problem: err.Error(), Fix: "check that the repository is not corrupted with `git fsck`"})
+	} else if len(remotes) == 0 {
+		findings = append(findings, Finding{
+			Problem: "the repository has no remote, so it cannot be updated",
+			Fix:     "add the remote the branches are merged on with `git remote add origin <url>`",
+		})
+	}
+	if err == nil {
+		for _, remote := range []string{options.TrunkRemote, options.PruneRemote} {
+			if len(remote) > 0 && !utils.Contains(remotes, remote) {
+				findings = append(findings, Finding{
+					Problem: fmt.Sprintf("the remote %s does not exist", remote),
+					Fix:     fmt.Sprintf("add the remote with `git remote add %s <url>`", remote),
+				})
+			}
+		}
+	}
+	if git.IsShallowRepository(ctx, path) {
+		findings = append(findings, Finding{
+			Problem: "the repository is a shallow clone, so merges may not be detected",
+			Fix:     "fetch the full history with `git fetch --unshallow`",
+		})
+	}
+	// submodules are compared against the trunks of their remote without checking them out, and their HEAD is usually
+	// detached at the commit recorded in the parent repository
+	if repo.Depth > 0 {
+		return findings
+	}
+	trunks, missing := getTrunks(ctx, path, options)
+	// the checkouts of the repo tool usually have a detached HEAD without a local main branch, so they are compared
+	// against the remote-tracking main branch instead, and nothing is checked out
+	if len(trunks) == 0 && len(repo.TrunkRemote) > 0 {
+		for _, trunk := range missing {
+			if !git.RemoteBranchExists(ctx, path, repo.TrunkRemote, trunk) {
+				findings = append(findings, Finding{
+					Problem: fmt.Sprintf("the trunk %s does not exist locally or on remote %s", trunk, repo.TrunkRemote),
+					Fix:     fmt.Sprintf("fetch the remote with `git fetch %s`", repo.TrunkRemote),
+				})
+			}
+		}
+		return findings
+	}
+	for _, trunk := range missing {
+		findings = append(findings, Finding{
+			Problem: fmt.Sprintf("the trunk %s does not exist locally, so the branches merged into it are not detected", trunk),
+			Fix:     getTrunkFix(repo, trunk),
+		})
+	}
+	if len(trunks) == 0 && !options.AllTrunks {
+		findings = append(findings, Finding{
+			Problem: "neither main nor master exists locally",
+			Fix:     getTrunkFix(repo, "main"),
+		})
+	}
+	if len(options.TrunkRemote) > 0 && utils.Contains(remotes, options.TrunkRemote) {
+		for _, trunk := range trunks {
+			if !hasRemoteTrunk(ctx, path, options, trunk) {
+				findings = append(findings, Finding{
+					Problem: fmt.Sprintf("the trunk %s does not exist on remote %s", trunk, options.TrunkRemote),
+					Fix:     fmt.Sprintf("fetch the remote with `git fetch %s`", options.TrunkRemote),
+				})
+			}
+		}
+	}
+	// bare repositories do not have a HEAD to check out, and are fetched rather than pulled
+	if repo.Bare {
+		return findings
+	}
+	branch, err := git.GetCurrentBranch(ctx, path)
+	if err != nil {
+		findings = append(findings, Finding{Problem: err.Error(), Fix: "check that the repository is not corrupted with `git fsck`"})
+	} else if len(branch) == 0 {
+		findings = append(findings, Finding{
+			Problem: "the HEAD is detached, the commits on it may be lost track of once the main branch is checked out",
+			Fix:     "create a branch for the commits with `git switch -c <branch>`",
+		})
+	}
+	// the trunks are only pulled when they are not compared against the trunks of another remote
+	if len(options.TrunkRemote) > 0 || len(remotes) == 0 {
+		return findings
+	}
+	for _, trunk := range trunks {
+		if len(git.GetUpstream(ctx, path, trunk)) == 0 {
+			findings = append(findings, Finding{
+				Problem: fmt.Sprintf("%s does not track a remote branch, so it cannot be pulled", trunk),
+				Fix:     fmt.Sprintf("set the upstream with `git branch --set-upstream-to=%s/%s %s`", remotes[0], trunk, trunk),
+			})
+		}
+	}
+	return findings
+}
+
+// getTrunks returns the trunks of the given repository that exist locally, like the full pipeline resolves them, and
+// the trunks that are missing. Only the first existing of the trunks is returned when they are not all trunks, in
+// which case none of them are missing.
+func getTrunks(ctx context.Context, path string, options Options) ([]string, []string) {
+	var existing, missing []string
+	for _, trunk := range options.Trunks {
+		if !git.BranchExists(ctx, path, trunk) {
+			missing = append(missing, trunk)
+			continue
+		}
+		existing = append(existing, trunk)
+		if !options.AllTrunks {
+			return existing, nil
+		}
+	}
+	if !options.AllTrunks {
+		return nil, nil
+	}
+	return existing, missing
+}
+
+// hasRemoteTrunk returns true if the given trunk has a counterpart on the trunk remote. Configured trunks must exist on
+// the remote under the same name, while the main branch may be named differently.
+func hasRemoteTrunk(ctx context.Context, path string, options Options, trunk string) bool {
+	if options.AllTrunks {
+		return git.RemoteBranchExists(ctx, path, options.TrunkRemote, trunk)
+	}
+	return len(git.GetRemoteTrunk(ctx, path, options.TrunkRemote, trunk)) > 0
+}
+
+// getTrunkFix returns how to create the given missing trunk of the given repository.
+func getTrunkFix(repo git.Repository, trunk string) string {
+	if repo.Bare {
+		return fmt.Sprintf("fetch the trunk with `git fetch origin %s:%s`", trunk, trunk)
+	}
+	return fmt.Sprintf("create the trunk from the remote with `git checkout -b %s origin/%s`", trunk, trunk)
+}
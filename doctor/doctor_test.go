@@ -0,0 +1,131 @@
+package doctor
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lopper/git"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// runGit runs the given Git command in the given directory.
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=lopper", "GIT_AUTHOR_EMAIL=lopper@example.com",
+		"GIT_COMMITTER_NAME=lopper", "GIT_COMMITTER_EMAIL=lopper@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		expected []Finding
+	}{
+		{name: "Supported", version: "2.39.2"},
+		{name: "Oldest Supported", version: MinGitVersion},
+		{name: "Platform Suffix", version: "2.37.1.windows.1"},
+		{
+			name:    "Too Old",
+			version: "2.16.6",
+			expected: []Finding{{
+				Problem: "git 2.16.6 is older than the supported 2.17.0",
+				Fix:     "upgrade Git to 2.17.0 or newer",
+			}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, checkVersion(test.version))
+		})
+	}
+}
+
+func TestCheckRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tests := []struct {
+		name    string
+		options Options
+		// clone are the extra arguments the repository is cloned with.
+		clone []string
+		// setup changes the cloned repository at the given path.
+		setup    func(t *testing.T, path string)
+		expected []string
+	}{
+		{
+			name:    "Healthy",
+			options: Options{Trunks: []string{"main", "master"}},
+		},
+		{
+			name:     "Missing Trunk",
+			options:  Options{Trunks: []string{"main", "develop"}, AllTrunks: true},
+			expected: []string{"the trunk develop does not exist locally, so the branches merged into it are not detected"},
+		},
+		{
+			name:    "Missing Main Branch",
+			options: Options{Trunks: []string{"main", "master"}},
+			setup: func(t *testing.T, path string) {
+				runGit(t, path, "checkout", "--quiet", "-b", "feature")
+				runGit(t, path, "branch", "--quiet", "-D", "main")
+			},
+			expected: []string{"neither main nor master exists locally"},
+		},
+		{
+			name:    "No Upstream",
+			options: Options{Trunks: []string{"main", "master"}},
+			setup: func(t *testing.T, path string) {
+				runGit(t, path, "branch", "--unset-upstream", "main")
+			},
+			expected: []string{"main does not track a remote branch, so it cannot be pulled"},
+		},
+		{
+			name:    "Detached HEAD",
+			options: Options{Trunks: []string{"main", "master"}},
+			setup: func(t *testing.T, path string) {
+				runGit(t, path, "checkout", "--quiet", "--detach")
+			},
+			expected: []string{"the HEAD is detached, the commits on it may be lost track of once the main branch is checked out"},
+		},
+		{
+			name:     "Shallow Clone",
+			options:  Options{Trunks: []string{"main", "master"}},
+			clone:    []string{"--depth=1"},
+			expected: []string{"the repository is a shallow clone, so merges may not be detected"},
+		},
+		{
+			name:     "Missing Remotes",
+			options:  Options{Trunks: []string{"main", "master"}, TrunkRemote: "upstream", PruneRemote: "fork"},
+			expected: []string{"the remote upstream does not exist", "the remote fork does not exist"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			upstream := filepath.Join(dir, "upstream")
+			require.NoError(t, os.Mkdir(upstream, 0755))
+			runGit(t, upstream, "init", "--quiet", "--initial-branch=main")
+			runGit(t, upstream, "commit", "--quiet", "--allow-empty", "--message=initial")
+			runGit(t, upstream, "commit", "--quiet", "--allow-empty", "--message=second")
+			// the URL is used for the clone, since the depth is ignored by local clones
+			runGit(t, dir, append(append([]string{"clone", "--quiet"}, test.clone...), "file://"+upstream, "project")...)
+			if test.setup != nil {
+				test.setup(t, filepath.Join(dir, "project"))
+			}
+			var problems []string
+			for _, finding := range CheckRepository(context.Background(), git.Repository{Path: dir, Name: "project"}, test.options) {
+				problems = append(problems, finding.Problem)
+			}
+			assert.Equal(t, test.expected, problems)
+		})
+	}
+}
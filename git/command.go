@@ -0,0 +1,117 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Command is a Git command that has been executed against a repository.
+type Command struct {
+	// Dir is the path of the repository the command was executed against.
+	Dir      string
+	Args     []string
+	Duration time.Duration
+	// ExitCode is the exit code of the command. It is -1 when the command could not be started.
+	ExitCode int
+	// Expected is true when the non-zero ExitCode is an expected answer of the command rather than a failure, like a
+	// probe for a ref that does not exist.
+	Expected bool
+	Stderr   string
+}
+
+// Failed returns true if the command exited with an exit code that was not expected.
+func (c Command) Failed() bool {
+	return c.ExitCode != 0 && !c.Expected
+}
+
+// Observer is notified of every Git command that has been executed. Observers may be notified concurrently.
+type Observer func(command Command)
+
+var observersMu sync.RWMutex
+var observers []Observer
+
+// AddObserver adds the given Observer to be notified of every Git command that is executed.
+func AddObserver(observer Observer) {
+	observersMu.Lock()
+	defer observersMu.Unlock()
+	observers = append(observers, observer)
+}
+
+func notify(command Command) {
+	observersMu.RLock()
+	defer observersMu.RUnlock()
+	for _, observer := range observers {
+		observer(command)
+	}
+}
+
+// waitDelay is how long to wait for the output of a killed command to be closed.
+const waitDelay = time.Second
+
+// cmd is a Git command that is run against the repository at the given path. It mirrors the methods of exec.Cmd, but
+// reports every execution to the observers.
+type cmd struct {
+	ctx  context.Context
+	path string
+	args []string
+	// expected are the non-zero exit codes that answer the command, rather than failing it.
+	expected []int
+}
+
+// command creates the Git command with the given arguments. The command is killed when the given context is done.
+func command(ctx context.Context, path string, args ...string) *cmd {
+	return &cmd{ctx: ctx, path: path, args: args}
+}
+
+// expect marks the given non-zero exit codes as expected answers of the command (e.g. 1 for a ref that does not
+// exist), so they are not reported as failures.
+func (c *cmd) expect(exitCodes ...int) *cmd {
+	c.expected = exitCodes
+	return c
+}
+
+// Run runs the command and waits for it to complete.
+func (c *cmd) Run() error {
+	_, err := c.execute(false)
+	return err
+}
+
+// Output runs the command and returns its standard output.
+func (c *cmd) Output() ([]byte, error) {
+	return c.execute(false)
+}
+
+// CombinedOutput runs the command and returns its combined standard output and standard error.
+func (c *cmd) CombinedOutput() ([]byte, error) {
+	return c.execute(true)
+}
+
+func (c *cmd) execute(combined bool) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	execCmd := exec.CommandContext(c.ctx, "git", append([]string{"-C", c.path}, c.args...)...)
+	execCmd.Stdout = &stdout
+	execCmd.Stderr = &stderr
+	if combined {
+		execCmd.Stderr = &stdout
+	}
+	// when the command is killed, child processes (e.g. ssh) may keep the output open, so stop waiting for it
+	execCmd.WaitDelay = waitDelay
+	start := time.Now()
+	err := execCmd.Run()
+	executed := Command{Dir: c.path, Args: c.args, Duration: time.Since(start), Stderr: stderr.String()}
+	if exitError, ok := err.(*exec.ExitError); ok {
+		executed.ExitCode = exitError.ExitCode()
+		for _, exitCode := range c.expected {
+			executed.Expected = executed.Expected || exitCode == executed.ExitCode
+		}
+		// like exec.Cmd.Output, include the standard error in the error
+		exitError.Stderr = stderr.Bytes()
+	} else if err != nil {
+		executed.ExitCode = -1
+	}
+	notify(executed)
+	return stdout.Bytes(), err
+}
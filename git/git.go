@@ -1,21 +1,36 @@
 package git
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"lopper/utils"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Repository represents a Git repository.
 type Repository struct {
 	Path string
 	Name string
+	Bare bool
+	// Depth is the submodule nesting level of the repository. Top level repositories have a depth of 0.
+	Depth int
+	// Trunk is the declared main branch of the repository (e.g. in a workspace manifest). When it is empty, the main
+	// branch is resolved from "main" and "master".
+	Trunk string
+	// TrunkRemote is the remote of the declared main branch. Its remote-tracking branch is used when the main branch has
+	// not been checked out locally, like in the detached checkouts of the repo tool.
+	TrunkRemote string
 }
 
 // IsGitRepository returns true if the given path is a Git repository.
-func IsGitRepository(path string) bool {
-	if err := exec.Command("git", "-C", path, "rev-parse").Run(); err != nil {
+func IsGitRepository(ctx context.Context, path string) bool {
+	if err := command(ctx, path, "rev-parse").expect(128).Run(); err != nil {
 		if _, ok := err.(*exec.ExitError); ok {
 			return false
 		}
@@ -23,19 +38,130 @@ func IsGitRepository(path string) bool {
 	return true
 }
 
+// IsBareRepository returns true if the given path is a bare Git repository (e.g. a mirror clone).
+func IsBareRepository(ctx context.Context, path string) bool {
+	out, err := command(ctx, path, "rev-parse", "--is-bare-repository").Output()
+	if err != nil {
+		return false
+	}
+	return utils.TrimNewline(string(out)) == "true"
+}
+
+// IsMirror returns true if the given repository mirrors the refs of the given remote (e.g. cloned with --mirror), so
+// every ref is overwritten when the remote is fetched.
+func IsMirror(ctx context.Context, path string, remote string) bool {
+	out, err := command(ctx, path, "config", "--get", fmt.Sprintf("remote.%s.mirror", remote)).expect(1).Output()
+	if err != nil {
+		return false
+	}
+	return utils.TrimNewline(string(out)) == "true"
+}
+
+// BranchExists returns true if the given branch exists locally in the given repository.
+func BranchExists(ctx context.Context, path string, branch string) bool {
+	return command(ctx, path, "show-ref", "--verify", "--quiet", "refs/heads/"+branch).expect(1).Run() == nil
+}
+
+// IsAncestor returns true if the given ancestor commit is an ancestor of, or the same as, the given commit in the
+// given repository. False is returned when either commit does not exist.
+func IsAncestor(ctx context.Context, path string, ancestor string, commit string) bool {
+	return command(ctx, path, "merge-base", "--is-ancestor", ancestor, commit).expect(1, 128).Run() == nil
+}
+
+// RemoteBranchExists returns true if the remote-tracking branch of the given branch of the given remote exists in the
+// given repository.
+func RemoteBranchExists(ctx context.Context, path string, remote string, branch string) bool {
+	return command(ctx, path, "show-ref", "--verify", "--quiet", fmt.Sprintf("refs/remotes/%s/%s", remote, branch)).expect(1).Run() == nil
+}
+
+// GetRemoteHead returns the remote-tracking branch (e.g. origin/main) of the default branch of the given remote.
+func GetRemoteHead(ctx context.Context, path string, remote string) (string, error) {
+	out, err := command(ctx, path, "symbolic-ref", "--short", fmt.Sprintf("refs/remotes/%s/HEAD", remote)).expect(128).Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("failed to get default branch of remote %s: %s", remote, exitError.Error())
+		}
+		return "", err
+	}
+	return utils.TrimNewline(string(out)), nil
+}
+
+// GetRemoteTrunk returns the remote-tracking branch of the main branch of the given remote (e.g. upstream/main),
+// preferring the branch with the same name as the local main branch. An empty string is returned when the remote does
+// not have a main branch.
+func GetRemoteTrunk(ctx context.Context, path string, remote string, localTrunk string) string {
+	if RemoteBranchExists(ctx, path, remote, localTrunk) {
+		return remote + "/" + localTrunk
+	}
+	if head, err := GetRemoteHead(ctx, path, remote); err == nil {
+		return head
+	}
+	for _, branch := range []string{"main", "master"} {
+		if RemoteBranchExists(ctx, path, remote, branch) {
+			return remote + "/" + branch
+		}
+	}
+	return ""
+}
+
+// GetCommonDir returns the absolute path of the Git directory that is shared by all worktrees of the given repository.
+func GetCommonDir(ctx context.Context, path string) (string, error) {
+	out, err := command(ctx, path, "rev-parse", "--git-common-dir").Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("failed to get common directory: %s", exitError.Error())
+		}
+		return "", err
+	}
+	dir := utils.TrimNewline(string(out))
+	// the common directory is relative to the given path when the path is the main worktree
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(path, dir)
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(dir)
+}
+
 // CheckoutBranch checks out the given branch in the given repository.
-func CheckoutBranch(path string, branch string) error {
-	if err := exec.Command("git", "-C", path, "checkout", branch).Run(); err != nil {
-		if _, ok := err.(*exec.ExitError); ok {
-			return fmt.Errorf("failed to checkout branch %s", branch)
+func CheckoutBranch(ctx context.Context, path string, branch string) error {
+	if err := command(ctx, path, "checkout", branch).Run(); err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("failed to checkout branch %s: %s", branch, utils.TrimNewline(string(exitError.Stderr)))
 		}
+		return err
 	}
 	return nil
 }
 
+// CheckoutCommit checks out the given commit in the given repository, detaching the HEAD.
+func CheckoutCommit(ctx context.Context, path string, commit string) error {
+	if err := command(ctx, path, "checkout", "--detach", commit).Run(); err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("failed to checkout commit %s: %s", commit, utils.TrimNewline(string(exitError.Stderr)))
+		}
+		return err
+	}
+	return nil
+}
+
+// GetHead returns the commit the HEAD of the given repository points to.
+func GetHead(ctx context.Context, path string) (string, error) {
+	out, err := command(ctx, path, "rev-parse", "HEAD").Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("failed to get HEAD: %s", exitError.Error())
+		}
+		return "", err
+	}
+	return utils.TrimNewline(string(out)), nil
+}
+
 // Pull updates the given repository.
-func Pull(path string) error {
-	if err := exec.Command("git", "-C", path, "pull").Run(); err != nil {
+func Pull(ctx context.Context, path string) error {
+	if err := command(ctx, path, "pull").Run(); err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			switch exitError.ExitCode() {
 			case 1:
@@ -50,9 +176,54 @@ func Pull(path string) error {
 	return nil
 }
 
+// Fetch updates the given local branches of the given repository from the branches with the same name on the given
+// remote. Unlike Pull, Fetch does not require a working tree, so it is used to update bare repositories. The branches
+// are given explicitly, since repositories cloned with --bare do not have a fetch refspec, and fetching every branch
+// would restore the branches that have been deleted locally.
+func Fetch(ctx context.Context, path string, remote string, branches []string) error {
+	args := []string{"fetch", remote}
+	for _, branch := range branches {
+		args = append(args, fmt.Sprintf("+refs/heads/%s:refs/heads/%s", branch, branch))
+	}
+	if err := command(ctx, path, args...).Run(); err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			switch exitError.ExitCode() {
+			case 128:
+				return fmt.Errorf("remote repository not found")
+			default:
+				return fmt.Errorf("failed to fetch latest changes: %s", exitError.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// FetchRemote updates the remote-tracking branches of the given remote of the given repository.
+func FetchRemote(ctx context.Context, path string, remote string) error {
+	if err := command(ctx, path, "fetch", remote).Run(); err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("failed to fetch latest changes of remote %s: %s", remote, exitError.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+// FetchPrunedRemote updates the remote-tracking branches of the given remote, removing the ones whose branch has been
+// deleted from the remote.
+func FetchPrunedRemote(ctx context.Context, path string, remote string) error {
+	if err := command(ctx, path, "fetch", "--prune", remote).Run(); err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("failed to fetch latest changes of remote %s: %s", remote, exitError.Error())
+		}
+		return err
+	}
+	return nil
+}
+
 // DeleteBranch deletes the given branch in the given repository.
-func DeleteBranch(path string, branch string) error {
-	if err := exec.Command("git", "-C", path, "branch", "-D", branch).Run(); err != nil {
+func DeleteBranch(ctx context.Context, path string, branch string) error {
+	if err := command(ctx, path, "branch", "-D", branch).Run(); err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			return fmt.Errorf("failed to delete branch %s: %s", branch, exitError.Error())
 		}
@@ -60,11 +231,353 @@ func DeleteBranch(path string, branch string) error {
 	return nil
 }
 
-var branchReplacer = strings.NewReplacer("*", "", " ", "")
+// DeleteRef deletes the ref of the given branch in the given repository. DeleteRef is used instead of DeleteBranch for
+// bare repositories.
+func DeleteRef(ctx context.Context, path string, branch string) error {
+	if err := command(ctx, path, "update-ref", "-d", "refs/heads/"+branch).Run(); err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("failed to delete ref of branch %s: %s", branch, exitError.Error())
+		}
+	}
+	return nil
+}
+
+// DeleteRemoteBranch deletes the given branch on the given remote of the given repository.
+func DeleteRemoteBranch(ctx context.Context, path string, remote string, branch string) error {
+	if out, err := command(ctx, path, "push", remote, "--delete", branch).CombinedOutput(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("failed to delete branch %s on remote %s: %s", branch, remote, utils.TrimNewline(string(out)))
+		}
+		return err
+	}
+	return nil
+}
+
+// IsClean returns true if the given repository or worktree does not have any uncommitted changes.
+func IsClean(ctx context.Context, path string) (bool, error) {
+	out, err := command(ctx, path, "status", "--porcelain").Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return false, fmt.Errorf("failed to get status: %s", exitError.Error())
+		}
+		return false, err
+	}
+	return len(strings.TrimSpace(string(out))) == 0, nil
+}
+
+// GetRemoteURL returns the URL of the given remote of the given repository.
+func GetRemoteURL(ctx context.Context, path string, remote string) (string, error) {
+	out, err := command(ctx, path, "remote", "get-url", remote).Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("failed to get URL of remote %s: %s", remote, exitError.Error())
+		}
+		return "", err
+	}
+	return utils.TrimNewline(string(out)), nil
+}
+
+// GetRemotes returns the names of the remotes of the given repository.
+func GetRemotes(ctx context.Context, path string) ([]string, error) {
+	out, err := command(ctx, path, "remote").Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("failed to get remotes: %s", exitError.Error())
+		}
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// GetCurrentBranch returns the branch checked out in the given repository. An empty string is returned when the HEAD
+// is detached.
+func GetCurrentBranch(ctx context.Context, path string) (string, error) {
+	out, err := command(ctx, path, "symbolic-ref", "--quiet", "--short", "HEAD").expect(1).Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			// symbolic-ref exits with 1 when the HEAD is not a symbolic ref
+			if exitError.ExitCode() == 1 {
+				return "", nil
+			}
+			return "", fmt.Errorf("failed to get current branch: %s", exitError.Error())
+		}
+		return "", err
+	}
+	return utils.TrimNewline(string(out)), nil
+}
+
+// IsShallowRepository returns true if the given repository is a shallow clone, which is missing part of the history.
+func IsShallowRepository(ctx context.Context, path string) bool {
+	out, err := command(ctx, path, "rev-parse", "--is-shallow-repository").Output()
+	if err != nil {
+		return false
+	}
+	return utils.TrimNewline(string(out)) == "true"
+}
+
+// IsPartialClone returns true if the given repository is a partial clone, which fetches missing objects lazily from
+// its promisor remote.
+func IsPartialClone(ctx context.Context, path string) bool {
+	out, err := command(ctx, path, "config", "--get", "extensions.partialClone").expect(1).Output()
+	if err != nil {
+		return false
+	}
+	return len(utils.TrimNewline(string(out))) > 0
+}
+
+// Deepen fetches more history into the given shallow repository, either the given number of commits or the commits
+// since the given date (e.g. 2022-01-01). A depth of 0 or an empty date are not used.
+func Deepen(ctx context.Context, path string, depth int, since string) error {
+	args := []string{"fetch"}
+	if depth > 0 {
+		args = append(args, fmt.Sprintf("--deepen=%d", depth))
+	}
+	if len(since) > 0 {
+		args = append(args, "--shallow-since="+since)
+	}
+	if err := command(ctx, path, args...).Run(); err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("failed to deepen history: %s", exitError.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+// GetGoneBranches returns the local branches of the given repository whose upstream branch has been deleted from the
+// remote, keyed by the branch with the upstream branch (e.g. origin/feature) as value.
+func GetGoneBranches(ctx context.Context, path string) (map[string]string, error) {
+	out, err := command(ctx, path, "for-each-ref", "refs/heads/", "--format=%(refname:short)%00%(upstream:short)%00%(upstream:track)").Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("failed to get upstream branches: %s", exitError.Error())
+		}
+		return nil, err
+	}
+	return parseGoneBranches(string(out)), nil
+}
+
+func parseGoneBranches(out string) map[string]string {
+	gone := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[2] == "[gone]" {
+			gone[fields[0]] = fields[1]
+		}
+	}
+	return gone
+}
+
+// GetUpstream returns the upstream branch (e.g. origin/main) the given branch of the given repository tracks. An empty
+// string is returned when no upstream has been configured.
+func GetUpstream(ctx context.Context, path string, branch string) string {
+	out, err := command(ctx, path, "rev-parse", "--abbrev-ref", branch+"@{upstream}").expect(128).Output()
+	if err != nil {
+		return ""
+	}
+	return utils.TrimNewline(string(out))
+}
+
+// GetVersion returns the version of Git (e.g. 2.39.2).
+func GetVersion(ctx context.Context) (string, error) {
+	out, err := command(ctx, ".", "version").Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("failed to get version: %s", exitError.Error())
+		}
+		return "", err
+	}
+	return parseVersion(string(out)), nil
+}
+
+func parseVersion(out string) string {
+	// the version may be followed by the platform (e.g. "git version 2.37.1 (Apple Git-137.1)")
+	fields := strings.Fields(strings.TrimPrefix(out, "git version "))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// CompareVersions compares the given Git versions numerically. The result is negative when a is older than b, 0 when
+// they are the same and positive when a is newer than b. Suffixes of the versions (e.g. ".windows.1") are ignored.
+func CompareVersions(a string, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < 3; i++ {
+		aPart, bPart := 0, 0
+		if i < len(aParts) {
+			aPart, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bPart, _ = strconv.Atoi(bParts[i])
+		}
+		if aPart != bPart {
+			return aPart - bPart
+		}
+	}
+	return 0
+}
+
+// Worktree represents a working tree attached to a Git repository.
+type Worktree struct {
+	Path     string
+	Head     string
+	Branch   string
+	Bare     bool
+	Detached bool
+	Locked   bool
+	Prunable bool
+}
+
+// GetWorktrees returns the worktrees attached to the given repository. The first worktree is always the main worktree.
+func GetWorktrees(ctx context.Context, path string) ([]Worktree, error) {
+	out, err := command(ctx, path, "worktree", "list", "--porcelain").Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("failed to list worktrees: %s", exitError.Error())
+		}
+		return nil, err
+	}
+	return parseWorktrees(string(out)), nil
+}
+
+func parseWorktrees(out string) []Worktree {
+	var worktrees []Worktree
+	var current *Worktree
+	for _, line := range strings.Split(out, "\n") {
+		// each worktree is separated by an empty line
+		if len(line) == 0 {
+			current = nil
+			continue
+		}
+		key, value := line, ""
+		if i := strings.Index(line, " "); i >= 0 {
+			key, value = line[:i], line[i+1:]
+		}
+		if key == "worktree" {
+			worktrees = append(worktrees, Worktree{Path: value})
+			current = &worktrees[len(worktrees)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		switch key {
+		case "HEAD":
+			current.Head = value
+		case "branch":
+			current.Branch = strings.TrimPrefix(value, "refs/heads/")
+		case "bare":
+			current.Bare = true
+		case "detached":
+			current.Detached = true
+		case "locked":
+			current.Locked = true
+		case "prunable":
+			current.Prunable = true
+		}
+	}
+	return worktrees
+}
+
+// RemoveWorktree removes the given worktree from the given repository. Worktrees with uncommitted changes are not
+// removed.
+func RemoveWorktree(ctx context.Context, path string, worktreePath string) error {
+	if out, err := command(ctx, path, "worktree", "remove", worktreePath).CombinedOutput(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("failed to remove worktree %s: %s", worktreePath, utils.TrimNewline(string(out)))
+		}
+		return err
+	}
+	return nil
+}
+
+// GetSubmodules returns the paths, relative to the given repository, of the initialized submodules of the repository.
+func GetSubmodules(ctx context.Context, path string) ([]string, error) {
+	// the submodules are listed as gitlinks of the index, separated by NUL so paths with spaces are not split
+	out, err := command(ctx, path, "ls-files", "--stage", "-z").Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("failed to get submodules: %s", exitError.Error())
+		}
+		return nil, err
+	}
+	var submodules []string
+	for _, submodule := range parseSubmodules(string(out)) {
+		// the directory of a submodule that has not been initialized is empty, without a .git file
+		if _, err := os.Stat(filepath.Join(path, submodule, ".git")); err == nil {
+			submodules = append(submodules, submodule)
+		}
+	}
+	return submodules, nil
+}
+
+func parseSubmodules(out string) []string {
+	var submodules []string
+	for _, entry := range strings.Split(out, "\x00") {
+		// each entry is the mode, object, and stage separated by spaces, followed by a tab and the path
+		info, path, ok := strings.Cut(entry, "\t")
+		if !ok {
+			continue
+		}
+		// submodules are recorded as gitlinks, which have their own mode
+		if mode, _, _ := strings.Cut(info, " "); mode != "160000" {
+			continue
+		}
+		// a conflicting submodule is listed once per stage, the entries are sorted by path
+		if len(submodules) > 0 && submodules[len(submodules)-1] == path {
+			continue
+		}
+		submodules = append(submodules, path)
+	}
+	return submodules
+}
+
+// Commit represents the commit at the tip of a branch.
+type Commit struct {
+	SHA     string
+	Subject string
+	Author  string
+	Date    time.Time
+}
+
+// GetBranchCommits returns the commit at the tip of every local branch in the given repository, keyed by the branch.
+func GetBranchCommits(ctx context.Context, path string) (map[string]Commit, error) {
+	out, err := command(ctx, path, "for-each-ref", "refs/heads/", "--format=%(refname:short)%00%(objectname)%00%(subject)%00%(authorname)%00%(committerdate:unix)").Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("failed to get branch commits: %s", exitError.Error())
+		}
+		return nil, err
+	}
+	return parseBranchCommits(string(out)), nil
+}
+
+func parseBranchCommits(out string) map[string]Commit {
+	commits := make(map[string]Commit)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 5 {
+			continue
+		}
+		commit := Commit{SHA: fields[1], Subject: fields[2], Author: fields[3]}
+		if seconds, err := strconv.ParseInt(fields[4], 10, 64); err == nil {
+			commit.Date = time.Unix(seconds, 0)
+		}
+		commits[fields[0]] = commit
+	}
+	return commits
+}
 
 // GetMergedBranches returns a list of merged branches in the given repository.
-func GetMergedBranches(path string, mainBranch string) ([]string, error) {
-	out, err := exec.Command("git", "-C", path, "branch", "--merged", mainBranch).Output()
+//
+// The main branch is any revision of the trunk, like a local branch (e.g. main) or a remote-tracking branch (e.g.
+// origin/main). The refs are compared against the revision directly, so a checked out main branch is not required.
+func GetMergedBranches(ctx context.Context, path string, mainBranch string) ([]string, error) {
+	out, err := command(ctx, path, "for-each-ref", "--merged", mainBranch, "refs/heads/", "--format=%(refname:short)").Output()
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			return nil, fmt.Errorf("failed to get merged branches: %s", exitError.Error())
@@ -73,19 +586,20 @@ func GetMergedBranches(path string, mainBranch string) ([]string, error) {
 	allBranches := strings.Split(string(out), "\n")
 	var mergedBranches []string
 	for _, branch := range allBranches {
-		b := branchReplacer.Replace(branch)
-		if len(b) > 0 && b != mainBranch {
-			mergedBranches = append(mergedBranches, b)
+		if len(branch) > 0 && branch != mainBranch {
+			mergedBranches = append(mergedBranches, branch)
 		}
 	}
 	return mergedBranches, nil
 }
 
-// GetMergedSquashedBranches returns a list of merged squashed branches in the given repository.
+// GetMergedSquashedBranches returns a list of merged squashed branches in the given repository. A branch that cannot be
+// compared (e.g. because its merge base is missing from a shallow clone) does not stop the other branches from being
+// compared, so the detected branches are returned along with the errors of the branches that could not be compared.
 //
 // Credit: https://github.com/not-an-aardvark/git-delete-squashed
-func GetMergedSquashedBranches(path string, mainBranch string, mergedBranches []string) ([]string, error) {
-	out, err := exec.Command("git", "-C", path, "for-each-ref", "refs/heads/", "--format=%(refname:short)").Output()
+func GetMergedSquashedBranches(ctx context.Context, path string, mainBranch string, mergedBranches []string) ([]string, error) {
+	out, err := command(ctx, path, "for-each-ref", "refs/heads/", "--format=%(refname:short)").Output()
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			return nil, fmt.Errorf("failed to get branches: %s", exitError.Error())
@@ -93,6 +607,7 @@ func GetMergedSquashedBranches(path string, mainBranch string, mergedBranches []
 	}
 	allBranches := strings.Split(string(out), "\n")
 	var squashedBranches []string
+	var errs []error
 	for _, branch := range allBranches {
 		if branch == mainBranch {
 			continue
@@ -104,25 +619,35 @@ func GetMergedSquashedBranches(path string, mainBranch string, mergedBranches []
 		if utils.Contains(mergedBranches, branch) {
 			continue
 		}
-		ancestorHash, err := exec.Command("git", "-C", path, "merge-base", mainBranch, branch).Output()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get ancestor hash: %w", err)
-		}
-		treeId, err := exec.Command("git", "-C", path, "rev-parse", fmt.Sprintf("%s^{tree}", branch)).Output()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get tree id: %w", err)
-		}
-		danglingCommitId, err := exec.Command("git", "-C", path, "commit-tree", utils.TrimNewline(string(treeId)), "-p", utils.TrimNewline(string(ancestorHash)), "-m", "Temp commit").Output()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get dangling commit id: %w", err)
-		}
-		commitId, err := exec.Command("git", "-C", path, "cherry", mainBranch, utils.TrimNewline(string(danglingCommitId))).Output()
+		squashed, err := isSquashed(ctx, path, mainBranch, branch)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get commit id: %w", err)
+			errs = append(errs, fmt.Errorf("branch %s: %w", branch, err))
+			continue
 		}
-		if strings.HasPrefix(string(commitId), "-") {
+		if squashed {
 			squashedBranches = append(squashedBranches, branch)
 		}
 	}
-	return squashedBranches, nil
+	return squashedBranches, errors.Join(errs...)
+}
+
+// isSquashed returns true if the changes of the given branch have been squash merged into the given main branch.
+func isSquashed(ctx context.Context, path string, mainBranch string, branch string) (bool, error) {
+	ancestorHash, err := command(ctx, path, "merge-base", mainBranch, branch).Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to get ancestor hash: %w", err)
+	}
+	treeId, err := command(ctx, path, "rev-parse", fmt.Sprintf("%s^{tree}", branch)).Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to get tree id: %w", err)
+	}
+	danglingCommitId, err := command(ctx, path, "commit-tree", utils.TrimNewline(string(treeId)), "-p", utils.TrimNewline(string(ancestorHash)), "-m", "Temp commit").Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to get dangling commit id: %w", err)
+	}
+	commitId, err := command(ctx, path, "cherry", mainBranch, utils.TrimNewline(string(danglingCommitId))).Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to get commit id: %w", err)
+	}
+	return strings.HasPrefix(string(commitId), "-"), nil
 }
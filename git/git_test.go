@@ -0,0 +1,204 @@
+package git
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseWorktrees(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Worktree
+	}{
+		{
+			name:  "Main Worktree Only",
+			input: "worktree /repo\nHEAD abc\nbranch refs/heads/main\n\n",
+			expected: []Worktree{
+				{Path: "/repo", Head: "abc", Branch: "main"},
+			},
+		},
+		{
+			name: "Linked Worktrees",
+			input: "worktree /repo\nbare\n\n" +
+				"worktree /repo-feature\nHEAD abc\nbranch refs/heads/feature/foo\nlocked\n\n" +
+				"worktree /repo-detached\nHEAD def\ndetached\nprunable gitdir file points to non-existent location\n\n",
+			expected: []Worktree{
+				{Path: "/repo", Bare: true},
+				{Path: "/repo-feature", Head: "abc", Branch: "feature/foo", Locked: true},
+				{Path: "/repo-detached", Head: "def", Detached: true, Prunable: true},
+			},
+		},
+		{
+			name:     "Empty",
+			input:    "",
+			expected: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, parseWorktrees(test.input))
+		})
+	}
+}
+
+func TestParseSubmodules(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name: "Submodules",
+			input: "160000 1234567890abcdef 0\tlibs/foo\x00" +
+				"100644 abcdef1234567890 0\tREADME.md\x00" +
+				"160000 abcdef1234567890 0\tlibs/my bar\x00",
+			expected: []string{"libs/foo", "libs/my bar"},
+		},
+		{
+			name:     "No Submodules",
+			input:    "100644 abcdef1234567890 0\tREADME.md\x00",
+			expected: nil,
+		},
+		{
+			name:     "Empty",
+			input:    "",
+			expected: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, parseSubmodules(test.input))
+		})
+	}
+}
+
+func TestParseBranchCommits(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]Commit
+	}{
+		{
+			name:  "Branches",
+			input: "main\x00abc\x00Initial commit\x00Jane Doe\x001600000000\nfeature/foo\x00def\x00Add foo\x00John Doe\x001600000060\n",
+			expected: map[string]Commit{
+				"main":        {SHA: "abc", Subject: "Initial commit", Author: "Jane Doe", Date: time.Unix(1600000000, 0)},
+				"feature/foo": {SHA: "def", Subject: "Add foo", Author: "John Doe", Date: time.Unix(1600000060, 0)},
+			},
+		},
+		{
+			name:     "No Branches",
+			input:    "",
+			expected: map[string]Commit{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, parseBranchCommits(test.input))
+		})
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Linux",
+			input:    "git version 2.39.2\n",
+			expected: "2.39.2",
+		},
+		{
+			name:     "macOS",
+			input:    "git version 2.37.1 (Apple Git-137.1)\n",
+			expected: "2.37.1",
+		},
+		{
+			name:     "Windows",
+			input:    "git version 2.38.1.windows.1\n",
+			expected: "2.38.1.windows.1",
+		},
+		{
+			name:     "Empty",
+			input:    "",
+			expected: "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, parseVersion(test.input))
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected int
+	}{
+		{
+			name:     "Older",
+			a:        "2.9.5",
+			b:        "2.17.0",
+			expected: -1,
+		},
+		{
+			name:     "Same",
+			a:        "2.38.1.windows.1",
+			b:        "2.38.1",
+			expected: 0,
+		},
+		{
+			name:     "Newer",
+			a:        "3.0",
+			b:        "2.17.0",
+			expected: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := CompareVersions(test.a, test.b)
+			switch {
+			case test.expected < 0:
+				assert.Negative(t, actual)
+			case test.expected > 0:
+				assert.Positive(t, actual)
+			default:
+				assert.Zero(t, actual)
+			}
+		})
+	}
+}
+
+func TestParseGoneBranches(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]string
+	}{
+		{
+			name: "Gone",
+			input: "main\x00origin/main\x00\n" +
+				"feature\x00origin/feature\x00[gone]\n" +
+				"ahead\x00origin/ahead\x00[ahead 1]\n" +
+				"local\x00\x00\n",
+			expected: map[string]string{"feature": "origin/feature"},
+		},
+		{
+			name:     "Empty",
+			input:    "",
+			expected: map[string]string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, parseGoneBranches(test.input))
+		})
+	}
+}
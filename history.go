@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"lopper/history"
+	"os"
+	"strconv"
+)
+
+// historyFileFlag returns the flag of the history file, which is shared by the app and the history command.
+func historyFileFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "history-file",
+		Usage: "the JSON lines file every run is recorded in, or nothing is recorded when it is empty",
+		Value: history.DefaultPath(),
+	}
+}
+
+// historySizeFlag returns the flag of the number of runs kept in the history file.
+func historySizeFlag() cli.Flag {
+	return &cli.IntFlag{
+		Name:  "history-size",
+		Usage: "the number of runs kept in the history file, or all of them when it is 0",
+		Value: 100,
+	}
+}
+
+// historyCommand looks up the recorded runs.
+func historyCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "history",
+		Usage: "lists the recorded runs, shows the details of a run, or compares two runs",
+		Flags: []cli.Flag{historyFileFlag()},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "lists the recorded runs",
+				Action: func(ctx *cli.Context) error {
+					runs, err := loadRuns(ctx)
+					if err != nil {
+						return err
+					}
+					return history.PrintRuns(os.Stdout, runs)
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "shows the details of a run, the last run by default",
+				ArgsUsage: "[ID]",
+				Action: func(ctx *cli.Context) error {
+					runs, err := loadRuns(ctx)
+					if err != nil {
+						return err
+					}
+					if len(runs) == 0 {
+						return fmt.Errorf("no runs have been recorded")
+					}
+					run := runs[len(runs)-1]
+					if ctx.Args().Present() {
+						if run, err = findRun(runs, ctx.Args().First()); err != nil {
+							return err
+						}
+					}
+					return history.PrintRun(os.Stdout, run)
+				},
+			},
+			{
+				Name:      "diff",
+				Usage:     "compares the deleted branches and outcome of the repositories of two runs, the last two runs by default",
+				ArgsUsage: "[OLD_ID NEW_ID]",
+				Action: func(ctx *cli.Context) error {
+					runs, err := loadRuns(ctx)
+					if err != nil {
+						return err
+					}
+					var oldRun, newRun history.Run
+					switch ctx.NArg() {
+					case 0:
+						if len(runs) < 2 {
+							return fmt.Errorf("at least two runs must have been recorded")
+						}
+						oldRun, newRun = runs[len(runs)-2], runs[len(runs)-1]
+					case 2:
+						if oldRun, err = findRun(runs, ctx.Args().Get(0)); err != nil {
+							return err
+						}
+						if newRun, err = findRun(runs, ctx.Args().Get(1)); err != nil {
+							return err
+						}
+					default:
+						return fmt.Errorf("either no or two run IDs must be given")
+					}
+					return history.PrintDiff(os.Stdout, history.Diff(oldRun, newRun))
+				},
+			},
+		},
+	}
+}
+
+// loadRuns loads the recorded runs. No runs are returned when nothing has been recorded yet.
+func loadRuns(ctx *cli.Context) ([]history.Run, error) {
+	path := ctx.String("history-file")
+	if len(path) == 0 {
+		return nil, fmt.Errorf("the history file is not set")
+	}
+	runs, warnings, err := history.Load(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	return runs, nil
+}
+
+// findRun returns the run with the given ID.
+func findRun(runs []history.Run, id string) (history.Run, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return history.Run{}, fmt.Errorf("invalid run ID %s", id)
+	}
+	run, ok := history.Find(runs, n)
+	if !ok {
+		return history.Run{}, fmt.Errorf("run %d has not been recorded", n)
+	}
+	return run, nil
+}
@@ -0,0 +1,371 @@
+// Package history records the runs of lopper in a JSON lines file, one run per line.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lopper/report"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Run is a recorded run of lopper.
+type Run struct {
+	// ID is the sequence number of the run, starting at 1.
+	ID int `json:"id"`
+	// Args are the command-line arguments lopper was run with.
+	Args   []string      `json:"args"`
+	Report report.Report `json:"report"`
+}
+
+// DefaultPath returns the default path of the history file. An empty string is returned when the cache directory of
+// the user cannot be determined.
+func DefaultPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "lopper", "history.jsonl")
+}
+
+// Append appends the given Run to the history file at the given path, numbered after the last recorded run, and
+// returns the numbered Run. The oldest runs beyond the given size are removed, unless the size is 0. The file is locked
+// while the run is appended, so concurrent runs of lopper are numbered in order.
+func Append(path string, run Run, size int) (Run, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return run, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return run, err
+	}
+	defer file.Close()
+	if err = lock(file, true); err != nil {
+		return run, fmt.Errorf("failed to lock history: %w", err)
+	}
+	defer unlock(file)
+	id, err := lastID(file)
+	if err != nil {
+		return run, err
+	}
+	run.ID = id + 1
+	data, err := json.Marshal(run)
+	if err != nil {
+		return run, err
+	}
+	end, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return run, err
+	}
+	// a partial last line (e.g. of a run that was killed while it was recorded) is ended, so the run gets its own line
+	if end > 0 {
+		last := make([]byte, 1)
+		if _, err = file.ReadAt(last, end-1); err != nil {
+			return run, err
+		}
+		if last[0] != '\n' {
+			data = append([]byte{'\n'}, data...)
+		}
+	}
+	// the line is written at once, so a concurrent reader never sees a partial line
+	if _, err = file.Write(append(data, '\n')); err != nil {
+		return run, err
+	}
+	if size > 0 {
+		return run, prune(file, run.ID, size)
+	}
+	return run, nil
+}
+
+// chunkSize is the number of bytes read at a time from the end of the history file.
+const chunkSize = 64 * 1024
+
+// lastID returns the ID of the last run recorded in the given file, or 0 when no run has been recorded. Since the runs
+// are numbered in order, only the end of the file is read.
+func lastID(file *os.File) (int, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	var tail []byte
+	for offset := info.Size(); offset > 0; {
+		n := int64(chunkSize)
+		if n > offset {
+			n = offset
+		}
+		offset -= n
+		chunk := make([]byte, n, n+int64(len(tail)))
+		if _, err = file.ReadAt(chunk, offset); err != nil {
+			return 0, err
+		}
+		tail = append(chunk, tail...)
+		// the first line may continue in the previous chunk, unless the start of the file has been reached
+		lines := bytes.Split(tail, []byte{'\n'})
+		first := 1
+		if offset == 0 {
+			first = 0
+		}
+		for i := len(lines) - 1; i >= first; i-- {
+			if id, ok := parseID(lines[i]); ok {
+				return id, nil
+			}
+		}
+		tail = lines[0]
+	}
+	return 0, nil
+}
+
+// parseID returns the ID of the run recorded on the given line. False is returned when the line is not a recorded run.
+func parseID(line []byte) (int, bool) {
+	var run struct {
+		ID int `json:"id"`
+	}
+	if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &run) != nil || run.ID < 1 {
+		return 0, false
+	}
+	return run.ID, true
+}
+
+// prune removes the oldest runs from the given file, whose last run has the given ID, so only the given number of runs
+// are kept. The file is only rewritten when the first run is too old.
+func prune(file *os.File, lastID int, size int) error {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(file)
+	first, err := reader.ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	// a malformed first line does not have an ID, which makes the file rewritten without it
+	if firstID, _ := parseID(first); lastID-firstID < size {
+		return nil
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	var kept [][]byte
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		if id, ok := parseID(line); ok && id > lastID-size {
+			kept = append(kept, line)
+		}
+	}
+	// the file is rewritten in place, since other runs of lopper may be waiting for the lock of this file
+	if err = file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = file.Write(append(bytes.Join(kept, []byte{'\n'}), '\n'))
+	return err
+}
+
+// Load loads the runs from the history file at the given path, oldest first. Malformed lines (e.g. of a run that was
+// interrupted while it was recorded) are left out, and returned as warnings. The returned error wraps os.ErrNotExist
+// when nothing has been recorded yet.
+func Load(path string) ([]Run, []error, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	if err = lock(file, false); err != nil {
+		return nil, nil, fmt.Errorf("failed to lock history: %w", err)
+	}
+	defer unlock(file)
+	var runs []Run
+	var warnings []error
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(data)) > 0 {
+			var run Run
+			if err := json.Unmarshal(data, &run); err != nil {
+				warnings = append(warnings, fmt.Errorf("failed to parse run on line %d: %w", line, err))
+			} else {
+				runs = append(runs, run)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return runs, warnings, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// Find returns the Run with the given ID. False is returned when there is no such Run.
+func Find(runs []Run, id int) (Run, bool) {
+	for _, run := range runs {
+		if run.ID == id {
+			return run, true
+		}
+	}
+	return Run{}, false
+}
+
+// RepositoryDiff is the difference of the outcome of a repository between two runs.
+type RepositoryDiff struct {
+	Path string
+	// OldState and NewState are the states of the repository in the old and new run. They are empty when the
+	// repository was not processed by the run.
+	OldState string
+	NewState string
+	// Added are the branches deleted by the new run, but not by the old run.
+	Added []string
+	// Removed are the branches deleted by the old run, but not by the new run.
+	Removed []string
+}
+
+// Diff returns the differences between the given runs, ordered by the path of the repositories. Repositories with the
+// same outcome in both runs are left out.
+func Diff(oldRun Run, newRun Run) []RepositoryDiff {
+	oldRepos := getRepositories(oldRun)
+	newRepos := getRepositories(newRun)
+	var paths []string
+	for path := range oldRepos {
+		paths = append(paths, path)
+	}
+	for path := range newRepos {
+		if _, ok := oldRepos[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	var diffs []RepositoryDiff
+	for _, path := range paths {
+		oldRepo, newRepo := oldRepos[path], newRepos[path]
+		diff := RepositoryDiff{
+			Path:     path,
+			OldState: oldRepo.State,
+			NewState: newRepo.State,
+			Added:    getMissingBranches(newRepo.Deleted, oldRepo.Deleted),
+			Removed:  getMissingBranches(oldRepo.Deleted, newRepo.Deleted),
+		}
+		if diff.OldState != diff.NewState || len(diff.Added) > 0 || len(diff.Removed) > 0 {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs
+}
+
+func getRepositories(run Run) map[string]report.Repository {
+	repositories := make(map[string]report.Repository)
+	for _, repository := range run.Report.Repositories {
+		repositories[repository.Path] = repository
+	}
+	return repositories
+}
+
+// getMissingBranches returns the names of the given branches that are not in others.
+func getMissingBranches(branches []report.Branch, others []report.Branch) []string {
+	names := make(map[string]bool)
+	for _, branch := range others {
+		names[branch.Name] = true
+	}
+	var missing []string
+	for _, branch := range branches {
+		if !names[branch.Name] {
+			missing = append(missing, branch.Name)
+		}
+	}
+	return missing
+}
+
+// PrintRuns prints the given runs as a table.
+func PrintRuns(w io.Writer, runs []Run) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tSTARTED\tDURATION\tDRY RUN\tREPOSITORIES\tDELETED\tFAILED")
+	for _, run := range runs {
+		deleted, failed := 0, 0
+		for _, repository := range run.Report.Repositories {
+			deleted += len(repository.Deleted)
+			if repository.State == "error" {
+				failed++
+			}
+		}
+		_, _ = fmt.Fprintf(
+			tw,
+			"%d\t%s\t%s\t%t\t%d\t%d\t%d\n",
+			run.ID,
+			run.Report.StartTime.Local().Format("2006-01-02 15:04:05"),
+			run.Report.EndTime.Sub(run.Report.StartTime).Round(time.Millisecond),
+			run.Report.DryRun,
+			len(run.Report.Repositories),
+			deleted,
+			failed,
+		)
+	}
+	return tw.Flush()
+}
+
+// PrintRun prints the details of the given Run.
+func PrintRun(w io.Writer, run Run) error {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Run %d\n", run.ID))
+	b.WriteString(fmt.Sprintf("Args - %s\n", strings.Join(run.Args, " ")))
+	b.WriteString(fmt.Sprintf("Started - %s\n", run.Report.StartTime.Local().Format(time.RFC3339)))
+	b.WriteString(fmt.Sprintf("Time Taken - %s\n", run.Report.EndTime.Sub(run.Report.StartTime).Round(time.Millisecond)))
+	b.WriteString(fmt.Sprintf("Dry Run - %t\n", run.Report.DryRun))
+	for _, repository := range run.Report.Repositories {
+		b.WriteString(fmt.Sprintf("\n%s (%s)\n", repository.Path, repository.State))
+		if len(repository.SkipReason) > 0 {
+			b.WriteString(fmt.Sprintf("   skipped: %s\n", repository.SkipReason))
+		}
+		for _, branch := range repository.Deleted {
+			b.WriteString(fmt.Sprintf("   deleted %s %s (%s: %s)\n", branch.Name, branch.SHA, branch.Strategy, branch.Reason))
+		}
+		for _, branch := range repository.Skipped {
+			b.WriteString(fmt.Sprintf("   skipped %s (%s)\n", branch.Name, branch.Reason))
+		}
+		for _, err := range repository.Errors {
+			b.WriteString(fmt.Sprintf("   error %s\n", err))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// PrintDiff prints the given differences between two runs.
+func PrintDiff(w io.Writer, diffs []RepositoryDiff) error {
+	var b strings.Builder
+	if len(diffs) == 0 {
+		b.WriteString("The runs have the same outcome.\n")
+	}
+	for _, diff := range diffs {
+		b.WriteString(diff.Path + "\n")
+		if diff.OldState != diff.NewState {
+			b.WriteString(fmt.Sprintf("   state %s -> %s\n", formatState(diff.OldState), formatState(diff.NewState)))
+		}
+		for _, branch := range diff.Added {
+			b.WriteString(fmt.Sprintf("   + %s\n", branch))
+		}
+		for _, branch := range diff.Removed {
+			b.WriteString(fmt.Sprintf("   - %s\n", branch))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatState returns the given state of a repository, which is empty when the repository was not processed.
+func formatState(state string) string {
+	if len(state) == 0 {
+		return "absent"
+	}
+	return state
+}
@@ -0,0 +1,170 @@
+package history
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lopper/report"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newRun(id int, repositories ...report.Repository) Run {
+	start := time.Date(2022, 10, id, 0, 0, 0, 0, time.UTC)
+	return Run{
+		ID:   id,
+		Args: []string{"-p", "/repos"},
+		Report: report.Report{
+			Paths:        []string{"/repos"},
+			StartTime:    start,
+			EndTime:      start.Add(time.Second),
+			Repositories: repositories,
+		},
+	}
+}
+
+func newRepository(path string, state string, deleted ...string) report.Repository {
+	repository := report.Repository{Name: filepath.Base(path), Path: path, State: state}
+	for _, branch := range deleted {
+		repository.Deleted = append(repository.Deleted, report.Branch{Name: branch, SHA: "abc123", Strategy: "merged"})
+	}
+	return repository
+}
+
+func TestAppendLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lopper", "history.jsonl")
+	_, _, err := Load(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	first, err := Append(path, newRun(0, newRepository("/repos/a", "completed", "feature")), 0)
+	require.NoError(t, err)
+	second, err := Append(path, newRun(0, newRepository("/repos/a", "completed")), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, first.ID)
+	assert.Equal(t, 2, second.ID)
+
+	runs, warnings, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Len(t, runs, 2)
+	assert.Equal(t, first.ID, runs[0].ID)
+	assert.Equal(t, first.Args, runs[0].Args)
+	assert.Equal(t, first.Report.Repositories, runs[0].Report.Repositories)
+	assert.True(t, first.Report.StartTime.Equal(runs[0].Report.StartTime))
+	run, ok := Find(runs, 2)
+	assert.True(t, ok)
+	assert.Equal(t, second.ID, run.ID)
+	_, ok = Find(runs, 3)
+	assert.False(t, ok)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"id\":1}\n\nnot json\n{\"id\":2}\n{\"id\":3,"), 0644))
+	// the malformed lines are skipped, and the next run is numbered after the last valid run
+	runs, warnings, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, runs, 2)
+	require.Len(t, warnings, 2)
+	assert.ErrorContains(t, warnings[0], "line 3")
+	assert.ErrorContains(t, warnings[1], "line 5")
+	run, err := Append(path, newRun(0), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, run.ID)
+	runs, warnings, err = Load(path)
+	require.NoError(t, err)
+	assert.Len(t, runs, 3)
+	assert.Len(t, warnings, 2)
+}
+
+func TestAppendSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	for i := 0; i < 5; i++ {
+		_, err := Append(path, newRun(0, newRepository("/repos/a", "completed")), 3)
+		require.NoError(t, err)
+	}
+	// the oldest runs are removed, and the numbering continues after them
+	runs, _, err := Load(path)
+	require.NoError(t, err)
+	var ids []int
+	for _, run := range runs {
+		ids = append(ids, run.ID)
+	}
+	assert.Equal(t, []int{3, 4, 5}, ids)
+}
+
+func TestLastID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	// the last run spans several chunks, so the ID is found across the chunks
+	long := strings.Repeat("a", 3*chunkSize)
+	require.NoError(t, os.WriteFile(path, []byte("{\"id\":1}\n{\"id\":2,\"args\":[\""+long+"\"]}\n"), 0644))
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	id, err := lastID(file)
+	require.NoError(t, err)
+	assert.Equal(t, 2, id)
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldRun   Run
+		newRun   Run
+		expected []RepositoryDiff
+	}{
+		{
+			name:     "Same",
+			oldRun:   newRun(1, newRepository("/repos/a", "completed", "feature")),
+			newRun:   newRun(2, newRepository("/repos/a", "completed", "feature")),
+			expected: nil,
+		},
+		{
+			name:   "Branches",
+			oldRun: newRun(1, newRepository("/repos/a", "completed", "feature", "fix")),
+			newRun: newRun(2, newRepository("/repos/a", "completed", "feature", "docs")),
+			expected: []RepositoryDiff{
+				{Path: "/repos/a", OldState: "completed", NewState: "completed", Added: []string{"docs"}, Removed: []string{"fix"}},
+			},
+		},
+		{
+			name:   "State",
+			oldRun: newRun(1, newRepository("/repos/a", "completed")),
+			newRun: newRun(2, newRepository("/repos/a", "error")),
+			expected: []RepositoryDiff{
+				{Path: "/repos/a", OldState: "completed", NewState: "error"},
+			},
+		},
+		{
+			name:   "Repositories",
+			oldRun: newRun(1, newRepository("/repos/b", "completed")),
+			newRun: newRun(2, newRepository("/repos/a", "completed", "feature")),
+			expected: []RepositoryDiff{
+				{Path: "/repos/a", NewState: "completed", Added: []string{"feature"}},
+				{Path: "/repos/b", OldState: "completed"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Diff(test.oldRun, test.newRun))
+		})
+	}
+}
+
+func TestPrintDiff(t *testing.T) {
+	var b bytes.Buffer
+	err := PrintDiff(&b, []RepositoryDiff{
+		{Path: "/repos/a", OldState: "completed", NewState: "completed", Added: []string{"docs"}, Removed: []string{"fix"}},
+		{Path: "/repos/b", OldState: "completed"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/repos/a\n"+
+		"   + docs\n"+
+		"   - fix\n"+
+		"/repos/b\n"+
+		"   state completed -> absent\n", b.String())
+}
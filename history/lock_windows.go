@@ -0,0 +1,23 @@
+//go:build windows
+
+package history
+
+import (
+	"golang.org/x/sys/windows"
+	"math"
+	"os"
+)
+
+// lock locks the given file, exclusively or shared, blocking until the lock is acquired.
+func lock(file *os.File, exclusive bool) error {
+	var flags uint32
+	if exclusive {
+		flags = windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+	return windows.LockFileEx(windows.Handle(file.Fd()), flags, 0, math.MaxUint32, math.MaxUint32, &windows.Overlapped{})
+}
+
+// unlock unlocks the given file.
+func unlock(file *os.File) {
+	_ = windows.UnlockFileEx(windows.Handle(file.Fd()), 0, math.MaxUint32, math.MaxUint32, &windows.Overlapped{})
+}
@@ -0,0 +1,78 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Branch is the branch the pre- and post-delete hooks are run for. It is passed to the hook as JSON on stdin and as
+// LOPPER_* environment variables.
+type Branch struct {
+	// Repository is the path of the repository. The hook is run in the repository.
+	Repository string `json:"repository"`
+	Branch     string `json:"branch"`
+	SHA        string `json:"sha"`
+	// Strategy is the strategy the branch has been detected as merged with (e.g. "squashed").
+	Strategy string `json:"strategy"`
+	// Reason is why the branch has been detected as merged.
+	Reason string `json:"reason"`
+	DryRun bool   `json:"dryRun"`
+}
+
+func (b Branch) env() []string {
+	return []string{
+		"LOPPER_REPOSITORY=" + b.Repository,
+		"LOPPER_BRANCH=" + b.Branch,
+		"LOPPER_SHA=" + b.SHA,
+		"LOPPER_STRATEGY=" + b.Strategy,
+		"LOPPER_REASON=" + b.Reason,
+		"LOPPER_DRY_RUN=" + strconv.FormatBool(b.DryRun),
+	}
+}
+
+// RunBranch runs the given hook command for the given branch.
+func RunBranch(ctx context.Context, command string, branch Branch) error {
+	return run(ctx, command, branch.Repository, branch.env(), branch)
+}
+
+// Run runs the given hook command with the given input as JSON on stdin.
+func Run(ctx context.Context, command string, input any) error {
+	return run(ctx, command, "", nil, input)
+}
+
+// run runs the given command with the shell of the platform. When the command exits with a non-zero exit code, the
+// returned error is the stderr of the command.
+func run(ctx context.Context, command string, dir string, env []string, input any) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			if message := strings.TrimSpace(stderr.String()); len(message) > 0 {
+				return fmt.Errorf("%s", message)
+			}
+			return fmt.Errorf("hook exited with code %d", exitError.ExitCode())
+		}
+		return fmt.Errorf("failed to run hook: %w", err)
+	}
+	return nil
+}
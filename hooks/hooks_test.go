@@ -0,0 +1,65 @@
+package hooks_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lopper/hooks"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunBranch(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		expectedOut string
+		expectedErr string
+	}{
+		{
+			name:        "Environment",
+			command:     `echo "$LOPPER_BRANCH $LOPPER_SHA $LOPPER_STRATEGY $LOPPER_DRY_RUN" > out`,
+			expectedOut: "feature abc123 squashed true\n",
+		},
+		{
+			name:        "Stdin",
+			command:     "cat > out",
+			expectedOut: `{"repository":"REPOSITORY","branch":"feature","sha":"abc123","strategy":"squashed","reason":"the changes have been squash merged into main","dryRun":true}`,
+		},
+		{
+			name:        "Stderr",
+			command:     "echo 'ticket is still open' >&2; exit 1",
+			expectedErr: "ticket is still open",
+		},
+		{
+			name:        "Exit Code",
+			command:     "exit 3",
+			expectedErr: "hook exited with code 3",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			branch := hooks.Branch{
+				Repository: dir,
+				Branch:     "feature",
+				SHA:        "abc123",
+				Strategy:   "squashed",
+				Reason:     "the changes have been squash merged into main",
+				DryRun:     true,
+			}
+			err := hooks.RunBranch(context.Background(), test.command, branch)
+			if len(test.expectedErr) > 0 {
+				assert.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			// the hook is run in the repository
+			out, err := os.ReadFile(filepath.Join(dir, "out"))
+			require.NoError(t, err)
+			assert.Equal(t, strings.ReplaceAll(test.expectedOut, "REPOSITORY", dir), string(out))
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"lopper/config"
+	"lopper/discovery"
+	"lopper/git"
+	"lopper/list"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// listCommand lists the branches of the repositories with their classification, without modifying anything.
+func listCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "lists the branches and whether they have been merged, without checking out, pulling or deleting anything",
+		Flags: append(pathFlags(),
+			&cli.StringSliceFlag{
+				Name:    "protected-branch",
+				Aliases: []string{"b"},
+				Usage:   "branches that are left out of the list (e.g. -b foo -b bar -b baz)",
+			},
+			&cli.IntFlag{
+				Name:    "concurrency",
+				Aliases: []string{"c"},
+				Usage:   "determines how many repositories to analyze concurrently",
+				Value:   1,
+			},
+			&cli.BoolFlag{
+				Name:  "recurse-submodules",
+				Usage: "lists the branches of the initialized submodules of each repository",
+			},
+			&cli.StringFlag{
+				Name:  "trunk-remote",
+				Usage: "compares the branches against the main branch of the given remote (e.g. upstream) instead of origin",
+			},
+			configFlag(),
+			&cli.StringFlag{
+				Name:  "sort",
+				Usage: fmt.Sprintf("the column to sort by (%s)", strings.Join(list.SortKeys, ", ")),
+				Value: "repository",
+			},
+			&cli.BoolFlag{
+				Name:  "reverse",
+				Usage: "reverses the sort order",
+			},
+			&cli.StringSliceFlag{
+				Name:  "classification",
+				Usage: "only lists branches with the given classifications (merged, squashed, unmerged)",
+			},
+			&cli.StringFlag{
+				Name:  "author",
+				Usage: "only lists branches whose last commit was authored by an author containing the given text",
+			},
+			&cli.DurationFlag{
+				Name:  "older-than",
+				Usage: "only lists branches whose last commit is older than the given duration (e.g. 720h)",
+			},
+		),
+		Action: func(ctx *cli.Context) error {
+			if ctx.Int("concurrency") < 1 {
+				return cli.Exit("--concurrency must be at least 1", exitFatal)
+			}
+			filter := list.Filter{Author: ctx.String("author"), OlderThan: ctx.Duration("older-than")}
+			for _, c := range ctx.StringSlice("classification") {
+				classification := list.Classification(c)
+				if classification != list.Merged && classification != list.Squashed && classification != list.Unmerged {
+					return fmt.Errorf("unknown classification %s, must be one of merged, squashed, unmerged", c)
+				}
+				filter.Classifications = append(filter.Classifications, classification)
+			}
+			c, err := loadConfig(ctx)
+			if err != nil {
+				return err
+			}
+			if err = checkTrunks(c.Trunks); err != nil {
+				return err
+			}
+			paths, err := getPaths(ctx)
+			if err != nil {
+				return err
+			}
+			projects, err := getProjects(ctx)
+			if err != nil {
+				return err
+			}
+			repositories, warnings, err := discovery.GetRepositories(ctx.Context, paths, projects, ctx.Bool("recurse-submodules"))
+			if err != nil {
+				return err
+			}
+			for _, warning := range warnings {
+				fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+			}
+			branches, failed := analyzeRepositories(ctx, repositories, c.Trunks)
+			now := time.Now()
+			branches = filter.Apply(branches, now)
+			if err = list.Sort(branches, ctx.String("sort"), ctx.Bool("reverse")); err != nil {
+				return err
+			}
+			if err = list.Print(os.Stdout, branches, now); err != nil {
+				return err
+			}
+			if failed {
+				return cli.Exit("", exitFailures)
+			}
+			return nil
+		},
+	}
+}
+
+// analyzeRepositories classifies the branches of the given repositories concurrently against their trunks. The
+// repositories that failed to be analyzed are reported on stderr.
+func analyzeRepositories(ctx *cli.Context, repositories []git.Repository, trunks []config.Trunk) ([]list.Branch, bool) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var branches []list.Branch
+	failed := false
+	// limit the number of repositories analyzed at the same time
+	semaphore := make(chan struct{}, ctx.Int("concurrency"))
+	for _, repository := range repositories {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(repository git.Repository) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			names, all := config.TrunkNames(trunks, repository)
+			repositoryTrunks := list.Trunks{Names: names, All: all, Remote: ctx.String("trunk-remote")}
+			analyzed, err := list.Analyze(ctx.Context, repository, repositoryTrunks, ctx.StringSlice("protected-branch"))
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", repository.Name, err)
+				failed = true
+				return
+			}
+			branches = append(branches, analyzed...)
+		}(repository)
+	}
+	wg.Wait()
+	return branches, failed
+}
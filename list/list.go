@@ -0,0 +1,298 @@
+// Package list classifies the branches of repositories without modifying them.
+package list
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"lopper/git"
+	"lopper/utils"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Classification is how a branch relates to the trunk of its repository.
+type Classification string
+
+const (
+	Merged   Classification = "merged"
+	Squashed Classification = "squashed"
+	Unmerged Classification = "unmerged"
+)
+
+// Branch is a classified local branch of a repository.
+type Branch struct {
+	Repository     string
+	Name           string
+	Classification Classification
+	// Trunk is the revision the branch was compared against (e.g. origin/main).
+	Trunk  string
+	Commit git.Commit
+}
+
+// Trunks are the trunk branches the branches of a repository are classified against.
+type Trunks struct {
+	// Names are the names of the trunk branches (e.g. main).
+	Names []string
+	// All is set when all the Names are trunks, instead of only the first that exists locally.
+	All bool
+	// Remote is the remote whose remote-tracking trunks the branches are compared against. When it is empty, the remote
+	// of the declared trunk of the repository is used, or else origin.
+	Remote string
+}
+
+// Analyze classifies the local branches of the given repository against the remote-tracking branches of its trunks,
+// so nothing is checked out, pulled or deleted. Bare repositories (e.g. mirror clones) are compared against their local
+// trunks, since their branches are updated from the remote directly. Each branch is classified against the first trunk
+// it has been merged into. Protected branches are left out.
+func Analyze(ctx context.Context, repo git.Repository, trunks Trunks, protectedBranches []string) ([]Branch, error) {
+	path := filepath.Join(repo.Path, repo.Name)
+	resolved, err := getTrunks(ctx, repo, path, trunks)
+	if err != nil {
+		return nil, err
+	}
+	// the trunks are merged into each other (e.g. main into develop), but they are not listed
+	detected := make([]string, 0, len(resolved))
+	for _, t := range resolved {
+		detected = append(detected, t.name)
+	}
+	classifications := make(map[string]Classification)
+	branchTrunks := make(map[string]string)
+	for _, t := range resolved {
+		merged, err := git.GetMergedBranches(ctx, path, t.revision)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range merged {
+			if !utils.Contains(detected, name) {
+				detected = append(detected, name)
+				classifications[name] = Merged
+				branchTrunks[name] = t.revision
+			}
+		}
+		squashed, err := git.GetMergedSquashedBranches(ctx, path, t.revision, detected)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range squashed {
+			detected = append(detected, name)
+			classifications[name] = Squashed
+			branchTrunks[name] = t.revision
+		}
+	}
+	commits, err := git.GetBranchCommits(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	var branches []Branch
+	for name, commit := range commits {
+		if containsTrunk(resolved, name) || utils.Contains(protectedBranches, name) {
+			continue
+		}
+		classification, ok := classifications[name]
+		if !ok {
+			// the unmerged branches are compared against the first trunk
+			classification = Unmerged
+			branchTrunks[name] = resolved[0].revision
+		}
+		branches = append(branches, Branch{
+			Repository:     repo.Name,
+			Name:           name,
+			Classification: classification,
+			Trunk:          branchTrunks[name],
+			Commit:         commit,
+		})
+	}
+	sort.Slice(branches, func(i, j int) bool {
+		return branches[i].Name < branches[j].Name
+	})
+	return branches, nil
+}
+
+// trunk is a trunk branch of a repository.
+type trunk struct {
+	// name is the name of the local branch (e.g. main).
+	name string
+	// revision is the revision the branches are compared against (e.g. origin/main).
+	revision string
+}
+
+func containsTrunk(trunks []trunk, name string) bool {
+	for _, t := range trunks {
+		if t.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getTrunks resolves the given trunks of the given repository the way the full pipeline does, but against the
+// remote-tracking branches instead of checking out and pulling the local branches.
+func getTrunks(ctx context.Context, repo git.Repository, path string, trunks Trunks) ([]trunk, error) {
+	var resolved []trunk
+	if repo.Bare {
+		for _, name := range trunks.Names {
+			if git.BranchExists(ctx, path, name) {
+				resolved = append(resolved, trunk{name: name, revision: name})
+				if !trunks.All {
+					break
+				}
+			}
+		}
+		if len(resolved) == 0 {
+			return nil, fmt.Errorf("the main branch does not exist")
+		}
+		return resolved, nil
+	}
+	// the declared main branch is tracked from the remote it has been declared with
+	remote := trunks.Remote
+	if len(remote) == 0 {
+		remote = repo.TrunkRemote
+	}
+	if len(remote) == 0 {
+		remote = "origin"
+	}
+	// configured and declared trunks must exist on the remote under the same name
+	if trunks.All {
+		for _, name := range trunks.Names {
+			if git.RemoteBranchExists(ctx, path, remote, name) {
+				resolved = append(resolved, trunk{name: name, revision: remote + "/" + name})
+			}
+		}
+		if len(resolved) == 0 {
+			return nil, fmt.Errorf("none of the trunks %s exist on remote %s", strings.Join(trunks.Names, ", "), remote)
+		}
+		return resolved, nil
+	}
+	// the main branch may be named differently on the remote, or not exist locally at all
+	name := ""
+	for _, n := range trunks.Names {
+		if git.BranchExists(ctx, path, n) {
+			name = n
+			break
+		}
+	}
+	revision := git.GetRemoteTrunk(ctx, path, remote, name)
+	if len(revision) == 0 {
+		return nil, fmt.Errorf("the remote-tracking branch of the main branch does not exist on remote %s", remote)
+	}
+	if len(name) == 0 {
+		name = strings.TrimPrefix(revision, remote+"/")
+	}
+	return []trunk{{name: name, revision: revision}}, nil
+}
+
+// Filter narrows down the listed branches. The zero value does not filter any branch.
+type Filter struct {
+	// Classifications are the classifications to list. All classifications are listed when it is empty.
+	Classifications []Classification
+	// Author lists the branches whose last commit has been authored by an author containing it, ignoring case.
+	Author string
+	// OlderThan lists the branches whose last commit is older than it.
+	OlderThan time.Duration
+}
+
+// Apply returns the branches that pass the Filter at the given time.
+func (f Filter) Apply(branches []Branch, now time.Time) []Branch {
+	var filtered []Branch
+	for _, branch := range branches {
+		if len(f.Classifications) > 0 && !containsClassification(f.Classifications, branch.Classification) {
+			continue
+		}
+		if len(f.Author) > 0 && !strings.Contains(strings.ToLower(branch.Commit.Author), strings.ToLower(f.Author)) {
+			continue
+		}
+		if f.OlderThan > 0 && now.Sub(branch.Commit.Date) < f.OlderThan {
+			continue
+		}
+		filtered = append(filtered, branch)
+	}
+	return filtered
+}
+
+func containsClassification(classifications []Classification, classification Classification) bool {
+	for _, c := range classifications {
+		if c == classification {
+			return true
+		}
+	}
+	return false
+}
+
+// SortKeys are the columns the branches can be sorted by.
+var SortKeys = []string{"repository", "branch", "classification", "age", "author"}
+
+// Sort sorts the given branches by the given column. Ties are broken by the repository and the branch.
+func Sort(branches []Branch, key string, reverse bool) error {
+	var less func(a, b Branch) bool
+	switch key {
+	case "repository":
+		less = func(a, b Branch) bool { return a.Repository < b.Repository }
+	case "branch":
+		less = func(a, b Branch) bool { return a.Name < b.Name }
+	case "classification":
+		less = func(a, b Branch) bool { return a.Classification < b.Classification }
+	case "age":
+		// the oldest branches first
+		less = func(a, b Branch) bool { return a.Commit.Date.Before(b.Commit.Date) }
+	case "author":
+		less = func(a, b Branch) bool { return a.Commit.Author < b.Commit.Author }
+	default:
+		return fmt.Errorf("unknown sort key %s, must be one of %s", key, strings.Join(SortKeys, ", "))
+	}
+	sort.SliceStable(branches, func(i, j int) bool {
+		a, b := branches[i], branches[j]
+		if reverse {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		return a.Name < b.Name
+	})
+	return nil
+}
+
+// Print prints the given branches as a table, with the age of the last commits relative to the given time.
+func Print(w io.Writer, branches []Branch, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "REPOSITORY\tBRANCH\tCLASSIFICATION\tAGE\tAUTHOR")
+	for _, branch := range branches {
+		_, _ = fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s\t%s\t%s\n",
+			branch.Repository,
+			branch.Name,
+			branch.Classification,
+			formatAge(now.Sub(branch.Commit.Date)),
+			branch.Commit.Author,
+		)
+	}
+	return tw.Flush()
+}
+
+// formatAge returns the given age in its largest whole unit (e.g. 3d).
+func formatAge(age time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < day:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	case age < 30*day:
+		return fmt.Sprintf("%dd", age/day)
+	case age < 365*day:
+		return fmt.Sprintf("%dmo", age/(30*day))
+	default:
+		return fmt.Sprintf("%dy", age/(365*day))
+	}
+}
@@ -0,0 +1,126 @@
+package list
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"lopper/git"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+func newBranch(repository string, name string, classification Classification, author string, age time.Duration) Branch {
+	return Branch{
+		Repository:     repository,
+		Name:           name,
+		Classification: classification,
+		Commit:         git.Commit{Author: author, Date: now.Add(-age)},
+	}
+}
+
+func TestFilter(t *testing.T) {
+	branches := []Branch{
+		newBranch("a", "feature", Merged, "Jane Doe", time.Hour),
+		newBranch("a", "fix", Squashed, "John Doe", 48*time.Hour),
+		newBranch("b", "wip", Unmerged, "Jane Doe", 72*time.Hour),
+	}
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []Branch
+	}{
+		{
+			name:     "None",
+			filter:   Filter{},
+			expected: branches,
+		},
+		{
+			name:     "Classifications",
+			filter:   Filter{Classifications: []Classification{Merged, Squashed}},
+			expected: branches[:2],
+		},
+		{
+			name:     "Author",
+			filter:   Filter{Author: "jane"},
+			expected: []Branch{branches[0], branches[2]},
+		},
+		{
+			name:     "Older Than",
+			filter:   Filter{OlderThan: 24 * time.Hour},
+			expected: branches[1:],
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.filter.Apply(branches, now))
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         string
+		reverse     bool
+		expected    []string
+		expectedErr bool
+	}{
+		{
+			name:     "Repository",
+			key:      "repository",
+			expected: []string{"feature", "fix", "wip"},
+		},
+		{
+			name:     "Age",
+			key:      "age",
+			expected: []string{"wip", "fix", "feature"},
+		},
+		{
+			name:     "Reverse",
+			key:      "classification",
+			reverse:  true,
+			expected: []string{"wip", "fix", "feature"},
+		},
+		{
+			name:        "Unknown",
+			key:         "size",
+			expectedErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			branches := []Branch{
+				newBranch("b", "wip", Unmerged, "Jane Doe", 72*time.Hour),
+				newBranch("a", "fix", Squashed, "John Doe", 48*time.Hour),
+				newBranch("a", "feature", Merged, "Jane Doe", time.Hour),
+			}
+			err := Sort(branches, test.key, test.reverse)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			var actual []string
+			for _, branch := range branches {
+				actual = append(actual, branch.Name)
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var b bytes.Buffer
+	err := Print(&b, []Branch{
+		newBranch("repo", "feature", Merged, "Jane Doe", 90*time.Minute),
+		newBranch("repo", "fix", Squashed, "John Doe", 400*24*time.Hour),
+	}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, ""+
+		"REPOSITORY  BRANCH   CLASSIFICATION  AGE  AUTHOR\n"+
+		"repo        feature  merged          1h   Jane Doe\n"+
+		"repo        fix      squashed        1y   John Doe\n",
+		b.String(),
+	)
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"lopper/git"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxStderrLength is the number of bytes of the stderr of a Git command that are logged.
+const maxStderrLength = 1024
+
+// ParseLevel returns the slog.Level of the given name (debug, info, warn or error).
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, fmt.Errorf("unknown log level %s, must be one of debug, info, warn, error", name)
+	}
+	return level, nil
+}
+
+// New returns a structured logger writing JSON lines to the given writer. Records below the given level are discarded.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// Discard returns a logger that discards all records.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+// GitObserver returns a git.Observer that logs every executed Git command to the given logger. Successful commands, and
+// commands that exited with an expected exit code (e.g. probes for refs that do not exist), are logged at the debug
+// level and failed commands at the warn level.
+func GitObserver(logger *slog.Logger) git.Observer {
+	return func(command git.Command) {
+		level := slog.LevelDebug
+		if command.Failed() {
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("dir", command.Dir),
+			slog.String("args", strings.Join(command.Args, " ")),
+			slog.Duration("duration", command.Duration),
+			slog.Int("exitCode", command.ExitCode),
+		}
+		if stderr := strings.TrimSpace(command.Stderr); len(stderr) > 0 {
+			attrs = append(attrs, slog.String("stderr", truncate(stderr, maxStderrLength)))
+		}
+		logger.LogAttrs(context.Background(), level, "git", attrs...)
+	}
+}
+
+// truncate returns the given string cut to at most the given number of bytes, marking that it has been cut. The string
+// is cut at the start of a character, so a multi-byte character is not split.
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	for length > 0 && !utf8.RuneStart(s[length]) {
+		length--
+	}
+	return s[:length] + "... (truncated)"
+}
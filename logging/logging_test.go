@@ -0,0 +1,129 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lopper/git"
+	"lopper/logging"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGitObserver(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		command  git.Command
+		expected map[string]any
+	}{
+		{
+			name:  "Succeeded",
+			level: "debug",
+			command: git.Command{
+				Dir:      "/path/to/repo",
+				Args:     []string{"checkout", "main"},
+				Duration: time.Second,
+			},
+			expected: map[string]any{
+				"level":    "DEBUG",
+				"msg":      "git",
+				"dir":      "/path/to/repo",
+				"args":     "checkout main",
+				"duration": float64(time.Second),
+				"exitCode": float64(0),
+			},
+		},
+		{
+			name:  "Failed",
+			level: "info",
+			command: git.Command{
+				Dir:      "/path/to/repo",
+				Args:     []string{"pull"},
+				Duration: time.Second,
+				ExitCode: 1,
+				Stderr:   strings.Repeat("a", 1025) + "\n",
+			},
+			expected: map[string]any{
+				"level":    "WARN",
+				"msg":      "git",
+				"dir":      "/path/to/repo",
+				"args":     "pull",
+				"duration": float64(time.Second),
+				"exitCode": float64(1),
+				"stderr":   strings.Repeat("a", 1024) + "... (truncated)",
+			},
+		},
+		{
+			name:  "Expected Exit Code",
+			level: "debug",
+			command: git.Command{
+				Dir:      "/path/to/repo",
+				Args:     []string{"show-ref", "--verify", "--quiet", "refs/heads/main"},
+				Duration: time.Second,
+				ExitCode: 1,
+				Expected: true,
+			},
+			expected: map[string]any{
+				"level":    "DEBUG",
+				"msg":      "git",
+				"dir":      "/path/to/repo",
+				"args":     "show-ref --verify --quiet refs/heads/main",
+				"duration": float64(time.Second),
+				"exitCode": float64(1),
+			},
+		},
+		{
+			name:  "Truncated Multi-Byte Character",
+			level: "info",
+			command: git.Command{
+				Dir:      "/path/to/repo",
+				Args:     []string{"pull"},
+				Duration: time.Second,
+				ExitCode: 1,
+				// the last character starts at byte 1023 and ends after the cut
+				Stderr: strings.Repeat("a", 1023) + "é",
+			},
+			expected: map[string]any{
+				"level":    "WARN",
+				"msg":      "git",
+				"dir":      "/path/to/repo",
+				"args":     "pull",
+				"duration": float64(time.Second),
+				"exitCode": float64(1),
+				"stderr":   strings.Repeat("a", 1023) + "... (truncated)",
+			},
+		},
+		{
+			name:  "Below Level",
+			level: "info",
+			command: git.Command{
+				Dir:  "/path/to/repo",
+				Args: []string{"checkout", "main"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var b bytes.Buffer
+			level, err := logging.ParseLevel(test.level)
+			require.NoError(t, err)
+			logging.GitObserver(logging.New(&b, level))(test.command)
+			if test.expected == nil {
+				assert.Empty(t, b.String())
+				return
+			}
+			var actual map[string]any
+			require.NoError(t, json.Unmarshal(b.Bytes(), &actual))
+			delete(actual, "time")
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestParseLevelUnknown(t *testing.T) {
+	_, err := logging.ParseLevel("verbose")
+	assert.Error(t, err)
+}
@@ -1,24 +1,33 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-isatty"
 	"github.com/urfave/cli/v2"
+	"io"
+	"log/slog"
+	"lopper/config"
+	"lopper/git"
+	"lopper/history"
+	"lopper/hooks"
+	"lopper/logging"
+	"lopper/provider"
 	"lopper/ui"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 func main() {
 	app := &cli.App{
 		Name:  "lopper",
 		Usage: "removes dead local Git branches",
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:     "path",
-				Aliases:  []string{"p"},
-				Usage:    "path to the repository or root directory containing Git repositories",
-				Required: true,
-			},
+		// the exit codes are handled below, rather than exiting from within the app
+		ExitErrHandler: func(*cli.Context, error) {},
+		Flags: append(append(pathFlags(),
 			&cli.StringSliceFlag{
 				Name:    "protected-branch",
 				Aliases: []string{"b"},
@@ -34,25 +43,317 @@ func main() {
 				Name:  "dry-run",
 				Usage: "runs thru the process without actually removing branches",
 			},
+			&cli.BoolFlag{
+				Name:  "prune-worktrees",
+				Usage: "removes clean linked worktrees that have a merged branch checked out",
+			},
+			&cli.BoolFlag{
+				Name:  "recurse-submodules",
+				Usage: "processes the initialized submodules of each repository",
+			},
+			&cli.StringFlag{
+				Name:  "trunk-remote",
+				Usage: "compares the branches against the main branch of the given remote (e.g. upstream) instead of the local main branch",
+			},
+			&cli.StringFlag{
+				Name:  "prune-remote",
+				Usage: "deletes the matching branches on the given remote (e.g. the fork) as well, requires --trunk-remote",
+			},
+			&cli.IntFlag{
+				Name:  "deepen",
+				Usage: "fetches the given number of commits of history into shallow clones to detect squash merges",
+			},
+			&cli.StringFlag{
+				Name:  "shallow-since",
+				Usage: "fetches the history since the given date (e.g. 2022-01-01) into shallow clones to detect squash merges",
+			},
+			&cli.BoolFlag{
+				Name:  "delete-gone-branches",
+				Usage: "deletes the branches of shallow clones whose upstream branch is gone, which may not have been merged",
+			},
+			&cli.StringFlag{
+				Name:  "summary-file",
+				Usage: "writes the summary of the run to the given file on exit",
+			},
+			configFlag(),
+			&cli.StringFlag{
+				Name:  "theme",
+				Usage: fmt.Sprintf("the theme to render the TUI with (%s)", strings.Join(ui.ThemeNames(), ", ")),
+				Value: ui.DefaultTheme,
+			},
+			&cli.BoolFlag{
+				Name:  "no-tui",
+				Usage: "prints the progress as plain text instead of rendering the TUI (default when the output is not a terminal)",
+			},
+			&cli.StringFlag{
+				Name:  "log-file",
+				Usage: "appends structured logs (JSON lines) to the given file",
+			},
+			&cli.StringFlag{
+				Name:  "log-level",
+				Usage: "the minimum level of the logs (debug, info, warn, error)",
+				Value: "info",
+			},
+			&cli.BoolFlag{
+				Name:  "verbose",
+				Usage: "logs every Git command, to stderr when the progress is printed as plain text unless --log-file is set (without --log-file, it has no effect in the TUI)",
+			},
+			historyFileFlag(),
+			historySizeFlag(),
+		), watchFlags()...),
+		Commands: []*cli.Command{
+			listCommand(),
+			doctorCommand(),
+			historyCommand(),
 		},
 		Action: func(ctx *cli.Context) error {
-			m := ui.NewModel(
-				ui.Path(ctx.String("path")),
+			paths, err := getPaths(ctx)
+			if err != nil {
+				return err
+			}
+			projects, err := getProjects(ctx)
+			if err != nil {
+				return err
+			}
+			if ctx.Int("concurrency") < 1 {
+				return cli.Exit("--concurrency must be at least 1", exitFatal)
+			}
+			if ctx.Int("history-size") < 0 {
+				return cli.Exit("--history-size must not be negative", exitFatal)
+			}
+			// the branches must not be pruned on the remote they are merged on
+			if ctx.IsSet("prune-remote") && (!ctx.IsSet("trunk-remote") || ctx.String("prune-remote") == ctx.String("trunk-remote")) {
+				return fmt.Errorf("--prune-remote requires a different --trunk-remote")
+			}
+			c, err := loadConfig(ctx)
+			if err != nil {
+				return err
+			}
+			themeName := c.Theme.Name
+			if ctx.IsSet("theme") {
+				themeName = ctx.String("theme")
+			}
+			// see https://no-color.org
+			theme, err := ui.NewTheme(themeName, c.Theme.Colors, c.Theme.Symbols, len(os.Getenv("NO_COLOR")) > 0)
+			if err != nil {
+				return err
+			}
+			keyMap, err := ui.NewKeyMap(c.Keys)
+			if err != nil {
+				return err
+			}
+			providers, err := newProviders(c.Providers)
+			if err != nil {
+				return err
+			}
+			if err = checkTrunks(c.Trunks); err != nil {
+				return err
+			}
+			// the alt-screen garbles the output when it is not a terminal, so print plain text instead. The watch mode
+			// runs unattended, so it prints plain text too.
+			plain := ctx.Bool("no-tui") || !isTerminal(os.Stdout) || ctx.IsSet("every")
+			logger, closeLog, err := newLogger(ctx, plain)
+			if err != nil {
+				return err
+			}
+			defer closeLog()
+			git.AddObserver(logging.GitObserver(logger))
+			options := []ui.Option{
+				ui.Paths(paths),
+				ui.ManifestProjects(projects),
 				ui.ProtectedBranches(ctx.StringSlice("protected-branch")),
 				ui.Concurrency(ctx.Int("concurrency")),
 				ui.DryRun(ctx.Bool("dry-run")),
-			)
-			if err := tea.NewProgram(m, tea.WithAltScreen()).Start(); err != nil {
-				return err
+				ui.PruneWorktrees(ctx.Bool("prune-worktrees")),
+				ui.RecurseSubmodules(ctx.Bool("recurse-submodules")),
+				ui.Trunks(c.Trunks),
+				ui.TrunkRemote(ctx.String("trunk-remote")),
+				ui.PruneRemote(ctx.String("prune-remote")),
+				ui.Deepen(ctx.Int("deepen")),
+				ui.ShallowSince(ctx.String("shallow-since")),
+				ui.DeleteGoneBranches(ctx.Bool("delete-gone-branches")),
+				ui.Styles(theme),
+				ui.Keys(keyMap),
+				ui.PreDeleteHook(c.Hooks.PreDelete),
+				ui.PostDeleteHook(c.Hooks.PostDelete),
+				ui.Providers(providers),
+				ui.Logger(logger),
+			}
+			if plain {
+				options = append(options, ui.Output(os.Stdout))
+			}
+			if ctx.IsSet("every") {
+				return watchRuns(ctx, func(runCtx context.Context) (*ui.Model, error) {
+					return run(runCtx, ctx, c, options, plain)
+				})
 			}
-			if m.Error() != nil {
-				return m.Error()
+			m, err := run(ctx.Context, ctx, c, options, plain)
+			if err != nil {
+				return err
 			}
-			return nil
+			return exitResult(m.Result(), ctx.Bool("dry-run"))
 		},
 	}
 	// start lopping some branches
 	if err := app.Run(os.Args); err != nil {
-		fmt.Println(err)
+		// the errors exiting with the outcome of the run have no message
+		if message := err.Error(); len(message) > 0 {
+			fmt.Fprintln(os.Stderr, message)
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+// run processes the repositories once with the given options. The summary file is written and the post-run hook is run
+// afterwards.
+func run(runCtx context.Context, ctx *cli.Context, c config.Config, options []ui.Option, plain bool) (*ui.Model, error) {
+	// the mouse is captured to scroll with the mouse wheel
+	programOptions := []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
+	if plain {
+		// there are no keys to handle, but a nil input is not supported, so give an input that is already drained.
+		// The terminal sequences of the program are discarded since the model prints the progress itself.
+		programOptions = []tea.ProgramOption{
+			tea.WithoutRenderer(),
+			tea.WithInput(strings.NewReader("")),
+			tea.WithOutput(io.Discard),
+		}
+	} else if ctx.String("repos-file") == stdinPath {
+		// the paths have been read from stdin, so read the keys from the terminal instead
+		programOptions = append(programOptions, tea.WithInputTTY())
+	}
+	m := ui.NewModel(options...)
+	if err := tea.NewProgram(m, programOptions...).Start(); err != nil {
+		return nil, err
+	}
+	if m.Error() != nil {
+		return nil, m.Error()
 	}
+	if path := ctx.String("summary-file"); len(path) > 0 {
+		if err := os.WriteFile(path, []byte(m.Summary()), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write summary: %w", err)
+		}
+	}
+	// a failing post-run hook does not change the outcome of the run
+	if len(c.Hooks.PostRun) > 0 {
+		if err := hooks.Run(runCtx, c.Hooks.PostRun, m.Report()); err != nil {
+			fmt.Fprintf(os.Stderr, "post-run hook failed: %s\n", err)
+		}
+	}
+	// every run is recorded, so what lopper did can be looked up later with the history command
+	if path := ctx.String("history-file"); len(path) > 0 {
+		if _, err := history.Append(path, history.Run{Args: os.Args[1:], Report: m.Report()}, ctx.Int("history-size")); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to record run: %s\n", err)
+		}
+	}
+	return m, nil
+}
+
+// configFlag returns the flag of the config file, which is shared by the app and the commands that read it.
+func configFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "config",
+		Usage: "path to the config file",
+		Value: config.DefaultPath(),
+	}
+}
+
+// loadConfig loads the config file. A missing config file is only an error when the path has been set explicitly.
+func loadConfig(ctx *cli.Context) (config.Config, error) {
+	c, err := config.Load(ctx.String("config"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !ctx.IsSet("config") {
+			return config.Config{}, nil
+		}
+		return c, fmt.Errorf("failed to load config: %w", err)
+	}
+	return c, nil
+}
+
+// newLogger returns the logger and a function to close the log file. The logs are discarded unless a log file has been
+// given, or the verbose logs are printed to stderr in plain mode, where they do not disturb the TUI.
+func newLogger(ctx *cli.Context, plain bool) (*slog.Logger, func(), error) {
+	level, err := logging.ParseLevel(ctx.String("log-level"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if ctx.Bool("verbose") {
+		level = slog.LevelDebug
+	}
+	if path := ctx.String("log-file"); len(path) > 0 {
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		return logging.New(file, level), func() { _ = file.Close() }, nil
+	}
+	if ctx.Bool("verbose") && plain {
+		return logging.New(os.Stderr, level), func() {}, nil
+	}
+	return logging.Discard(), func() {}, nil
+}
+
+// newProviders returns the configured hosting providers keyed by their host.
+func newProviders(providers []config.Provider) (map[string]provider.Provider, error) {
+	result := make(map[string]provider.Provider)
+	for _, p := range providers {
+		// the token is read from the environment, so it does not have to be stored in the config file
+		hostingProvider, err := provider.New(provider.Kind(p.Type), p.Host, p.URL, os.Getenv(p.TokenEnv))
+		if err != nil {
+			return nil, err
+		}
+		result[p.Host] = hostingProvider
+	}
+	return result, nil
+}
+
+// checkTrunks returns an error when the configured trunk branches of the repositories are invalid.
+func checkTrunks(trunks []config.Trunk) error {
+	for _, t := range trunks {
+		// the pattern is validated up front, since matching a repository against it silently fails otherwise
+		if _, err := filepath.Match(t.Repositories, ""); err != nil {
+			return fmt.Errorf("invalid trunk pattern %s: %w", t.Repositories, err)
+		}
+		if len(t.Branches) == 0 {
+			return fmt.Errorf("no trunk branches configured for %s", t.Repositories)
+		}
+	}
+	return nil
+}
+
+// The exit codes of lopper. Scripts can use them to branch on the outcome of a run.
+const (
+	// exitFatal is used when lopper could not run at all (e.g. the path does not exist).
+	exitFatal = 1
+	// exitFailures is used when some repositories failed to be processed.
+	exitFailures = 2
+	// exitCandidates is used when a dry run found branches that would be deleted.
+	exitCandidates = 3
+)
+
+// exitCode returns the exit code of the given error returned by the app. Errors without an exit code are fatal.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var coder cli.ExitCoder
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+	return exitFatal
+}
+
+// exitResult returns the error that exits lopper with the exit code matching the given result. Nil is returned when
+// lopper should exit successfully.
+func exitResult(result ui.Result, dryRun bool) error {
+	if result.Failed > 0 {
+		return cli.Exit("", exitFailures)
+	}
+	if dryRun && result.Branches > 0 {
+		return cli.Exit("", exitCandidates)
+	}
+	return nil
+}
+
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
 }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+	"lopper/ui"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   ui.Result
+		dryRun   bool
+		err      error
+		expected int
+	}{
+		{
+			name:     "Success",
+			result:   ui.Result{Processed: 2, Branches: 3},
+			expected: 0,
+		},
+		{
+			name:     "Dry Run Without Candidates",
+			result:   ui.Result{Processed: 2},
+			dryRun:   true,
+			expected: 0,
+		},
+		{
+			name:     "Fatal",
+			err:      errors.New("path does not exist"),
+			expected: exitFatal,
+		},
+		{
+			name:     "Fatal Exit",
+			err:      cli.Exit("--concurrency must be at least 1", exitFatal),
+			expected: exitFatal,
+		},
+		{
+			name:     "Failures",
+			result:   ui.Result{Processed: 1, Failed: 1, Branches: 3},
+			expected: exitFailures,
+		},
+		{
+			name:     "Failures On Dry Run",
+			result:   ui.Result{Processed: 1, Failed: 1, Branches: 3},
+			dryRun:   true,
+			expected: exitFailures,
+		},
+		{
+			name:     "Candidates",
+			result:   ui.Result{Processed: 2, Skipped: 1, Branches: 3},
+			dryRun:   true,
+			expected: exitCandidates,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.err
+			if err == nil {
+				err = exitResult(test.result, test.dryRun)
+			}
+			assert.Equal(t, test.expected, exitCode(err))
+		})
+	}
+}
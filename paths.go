@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"lopper/discovery"
+	"os"
+)
+
+// stdinPath is the path of the repos file that reads the paths from stdin instead.
+const stdinPath = "-"
+
+// pathFlags returns the flags the repositories are given with, which are shared by the app and its commands.
+func pathFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:    "path",
+			Aliases: []string{"p"},
+			Usage:   "paths to repositories or root directories containing Git repositories (e.g. -p foo -p bar), required unless --repos-file is set",
+		},
+		&cli.StringFlag{
+			Name:  "repos-file",
+			Usage: "reads newline-separated paths to repositories from the given file, or stdin when it is - (e.g. the output of find)",
+		},
+		&cli.StringFlag{
+			Name:  "manifest",
+			Usage: "reads the repositories and their default branches from the given workspace manifest (repo tool XML or a YAML list)",
+		},
+	}
+}
+
+// getPaths returns the paths given with --path followed by the paths read from --repos-file.
+func getPaths(ctx *cli.Context) ([]string, error) {
+	// the path is not a required flag, since the repositories can be given with the repos file or manifest instead
+	if !ctx.IsSet("path") && !ctx.IsSet("repos-file") && !ctx.IsSet("manifest") {
+		return nil, fmt.Errorf("required flag \"path\" not set")
+	}
+	paths := ctx.StringSlice("path")
+	if file := ctx.String("repos-file"); len(file) > 0 {
+		read, err := readPaths(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read repos file: %w", err)
+		}
+		paths = append(paths, read...)
+	}
+	return paths, nil
+}
+
+// getProjects returns the projects of the workspace manifest given with --manifest.
+func getProjects(ctx *cli.Context) ([]discovery.Project, error) {
+	file := ctx.String("manifest")
+	if len(file) == 0 {
+		return nil, nil
+	}
+	projects, err := discovery.ReadManifest(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return projects, nil
+}
+
+func readPaths(file string) ([]string, error) {
+	if file == stdinPath {
+		return discovery.ReadPaths(os.Stdin)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return discovery.ReadPaths(f)
+}
@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// gitea is the Provider for Gitea and Forgejo.
+//
+// See https://gitea.com/api/swagger#/repository/repoListPullRequests
+type gitea struct {
+	client
+}
+
+type giteaPullRequest struct {
+	Number    int       `json:"number"`
+	HTMLURL   string    `json:"html_url"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Merged    bool      `json:"merged"`
+	Head      struct {
+		Ref    string `json:"ref"`
+		SHA    string `json:"sha"`
+		RepoID int64  `json:"repo_id"`
+	} `json:"head"`
+	Base struct {
+		RepoID int64 `json:"repo_id"`
+	} `json:"base"`
+}
+
+// giteaPageSize is the number of pull requests requested per page.
+const giteaPageSize = 50
+
+func (g *gitea) GetOpenPullRequests(ctx context.Context, repo Repository) ([]PullRequest, error) {
+	return g.getPullRequests(ctx, repo, "open", time.Time{})
+}
+
+func (g *gitea) GetMergedPullRequests(ctx context.Context, repo Repository, since time.Time) ([]PullRequest, error) {
+	// merged pull requests are closed pull requests that are flagged as merged
+	return g.getPullRequests(ctx, repo, "closed", since)
+}
+
+func (g *gitea) getPullRequests(ctx context.Context, repo Repository, state string, since time.Time) ([]PullRequest, error) {
+	var result []PullRequest
+	// the most recently updated pull requests come first, so the paging stops at the first one updated before since
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("state", state)
+		query.Set("sort", "recentupdate")
+		query.Set("limit", strconv.Itoa(giteaPageSize))
+		query.Set("page", strconv.Itoa(page))
+		var pullRequests []giteaPullRequest
+		path := fmt.Sprintf("/repos/%s/%s/pulls?%s", url.PathEscape(repo.Owner), url.PathEscape(repo.Name), query.Encode())
+		if err := g.getJSON(ctx, path, g.header(), &pullRequests); err != nil {
+			return nil, err
+		}
+		for _, pr := range pullRequests {
+			if pr.UpdatedAt.Before(since) {
+				return result, nil
+			}
+			// the head branch of a pull request from a fork is not a branch of the repository, even if it has the same name
+			if pr.Head.RepoID != pr.Base.RepoID {
+				continue
+			}
+			if state != "closed" || pr.Merged {
+				result = append(result, PullRequest{Number: pr.Number, Head: pr.Head.Ref, HeadSHA: pr.Head.SHA, URL: pr.HTMLURL})
+			}
+		}
+		if len(pullRequests) < giteaPageSize {
+			return result, nil
+		}
+	}
+}
+
+func (g *gitea) header() http.Header {
+	header := http.Header{}
+	if len(g.token) > 0 {
+		header.Set("Authorization", "token "+g.token)
+	}
+	return header
+}
@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitHub is the Provider for GitHub and GitHub Enterprise Server.
+//
+// See https://docs.github.com/en/rest/pulls/pulls#list-pull-requests
+type gitHub struct {
+	client
+}
+
+type gitHubPullRequest struct {
+	Number    int       `json:"number"`
+	HTMLURL   string    `json:"html_url"`
+	UpdatedAt time.Time `json:"updated_at"`
+	MergedAt  *string   `json:"merged_at"`
+	Head      struct {
+		Ref  string `json:"ref"`
+		SHA  string `json:"sha"`
+		Repo *struct {
+			FullName string `json:"full_name"`
+		} `json:"repo"`
+	} `json:"head"`
+}
+
+// gitHubPageSize is the number of pull requests requested per page.
+const gitHubPageSize = 100
+
+func (g *gitHub) GetOpenPullRequests(ctx context.Context, repo Repository) ([]PullRequest, error) {
+	return g.getPullRequests(ctx, repo, "open", time.Time{})
+}
+
+func (g *gitHub) GetMergedPullRequests(ctx context.Context, repo Repository, since time.Time) ([]PullRequest, error) {
+	// merged pull requests are closed pull requests with a merge time
+	return g.getPullRequests(ctx, repo, "closed", since)
+}
+
+func (g *gitHub) getPullRequests(ctx context.Context, repo Repository, state string, since time.Time) ([]PullRequest, error) {
+	var result []PullRequest
+	// the most recently updated pull requests come first, so the paging stops at the first one updated before since
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("state", state)
+		query.Set("sort", "updated")
+		query.Set("direction", "desc")
+		query.Set("per_page", strconv.Itoa(gitHubPageSize))
+		query.Set("page", strconv.Itoa(page))
+		var pullRequests []gitHubPullRequest
+		path := fmt.Sprintf("/repos/%s/%s/pulls?%s", url.PathEscape(repo.Owner), url.PathEscape(repo.Name), query.Encode())
+		if err := g.getJSON(ctx, path, g.header(), &pullRequests); err != nil {
+			return nil, err
+		}
+		for _, pr := range pullRequests {
+			if pr.UpdatedAt.Before(since) {
+				return result, nil
+			}
+			// the head branch of a pull request from a fork is not a branch of the repository, even if it has the same name
+			if pr.Head.Repo == nil || !strings.EqualFold(pr.Head.Repo.FullName, repo.Path()) {
+				continue
+			}
+			if state == "closed" && pr.MergedAt == nil {
+				continue
+			}
+			result = append(result, PullRequest{Number: pr.Number, Head: pr.Head.Ref, HeadSHA: pr.Head.SHA, URL: pr.HTMLURL})
+		}
+		if len(pullRequests) < gitHubPageSize {
+			return result, nil
+		}
+	}
+}
+
+func (g *gitHub) header() http.Header {
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github+json")
+	if len(g.token) > 0 {
+		header.Set("Authorization", "Bearer "+g.token)
+	}
+	return header
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// gitLab is the Provider for GitLab.
+//
+// See https://docs.gitlab.com/ee/api/merge_requests.html#list-project-merge-requests
+type gitLab struct {
+	client
+}
+
+type gitLabMergeRequest struct {
+	IID             int    `json:"iid"`
+	WebURL          string `json:"web_url"`
+	SourceBranch    string `json:"source_branch"`
+	SourceProjectID int    `json:"source_project_id"`
+	TargetProjectID int    `json:"target_project_id"`
+	SHA             string `json:"sha"`
+}
+
+// gitLabPageSize is the number of merge requests requested per page.
+const gitLabPageSize = 100
+
+func (g *gitLab) GetOpenPullRequests(ctx context.Context, repo Repository) ([]PullRequest, error) {
+	return g.getMergeRequests(ctx, repo, "opened", time.Time{})
+}
+
+func (g *gitLab) GetMergedPullRequests(ctx context.Context, repo Repository, since time.Time) ([]PullRequest, error) {
+	return g.getMergeRequests(ctx, repo, "merged", since)
+}
+
+func (g *gitLab) getMergeRequests(ctx context.Context, repo Repository, state string, since time.Time) ([]PullRequest, error) {
+	var result []PullRequest
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("state", state)
+		query.Set("per_page", strconv.Itoa(gitLabPageSize))
+		query.Set("page", strconv.Itoa(page))
+		if !since.IsZero() {
+			query.Set("updated_after", since.UTC().Format(time.RFC3339))
+		}
+		var mergeRequests []gitLabMergeRequest
+		// the project is identified by its URL-encoded path, which includes the subgroups
+		path := fmt.Sprintf("/projects/%s/merge_requests?%s", url.PathEscape(repo.Path()), query.Encode())
+		if err := g.getJSON(ctx, path, g.header(), &mergeRequests); err != nil {
+			return nil, err
+		}
+		for _, mr := range mergeRequests {
+			// the source branch of a merge request from a fork is not a branch of the project, even if it has the same name
+			if mr.SourceProjectID != mr.TargetProjectID {
+				continue
+			}
+			result = append(result, PullRequest{Number: mr.IID, Head: mr.SourceBranch, HeadSHA: mr.SHA, URL: mr.WebURL})
+		}
+		if len(mergeRequests) < gitLabPageSize {
+			return result, nil
+		}
+	}
+}
+
+func (g *gitLab) header() http.Header {
+	header := http.Header{}
+	if len(g.token) > 0 {
+		header.Set("PRIVATE-TOKEN", g.token)
+	}
+	return header
+}
@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PullRequest is a pull request, or a merge request in GitLab terms, of a hosting provider.
+type PullRequest struct {
+	Number int
+	// Head is the branch the changes of the pull request are on.
+	Head string
+	// HeadSHA is the commit at the tip of the head branch.
+	HeadSHA string
+	URL     string
+}
+
+// Provider is a hosting provider of Git repositories, like GitHub.
+type Provider interface {
+	// GetOpenPullRequests returns the open pull requests of the given repository.
+	GetOpenPullRequests(ctx context.Context, repo Repository) ([]PullRequest, error)
+	// GetMergedPullRequests returns the merged pull requests of the given repository that have been updated since the
+	// given time, or all of them when it is zero. The head SHA of a merged pull request is the tip of the head branch at
+	// the time of the merge.
+	GetMergedPullRequests(ctx context.Context, repo Repository, since time.Time) ([]PullRequest, error)
+}
+
+// Kind is the kind of hosting provider.
+type Kind string
+
+const (
+	GitHub Kind = "github"
+	GitLab Kind = "gitlab"
+	Gitea  Kind = "gitea"
+)
+
+// timeout is the time a request to the API of a provider may take.
+const timeout = 30 * time.Second
+
+// New returns the Provider of the given kind. The API is reached at the given base URL, or at the default base URL of
+// the provider for the given host when it is empty. An empty token makes unauthenticated requests.
+func New(kind Kind, host string, baseURL string, token string) (Provider, error) {
+	if len(baseURL) == 0 {
+		baseURL = DefaultBaseURL(kind, host)
+	}
+	c := client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+	switch kind {
+	case GitHub:
+		return &gitHub{c}, nil
+	case GitLab:
+		return &gitLab{c}, nil
+	case Gitea:
+		return &gitea{c}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %s, must be one of %s, %s, %s", kind, GitHub, GitLab, Gitea)
+	}
+}
+
+// DefaultBaseURL returns the base URL of the API of the given kind of provider hosted at the given host.
+func DefaultBaseURL(kind Kind, host string) string {
+	switch kind {
+	case GitHub:
+		// GitHub Enterprise Server serves the API under a path rather than a subdomain
+		if host == "github.com" {
+			return "https://api.github.com"
+		}
+		return fmt.Sprintf("https://%s/api/v3", host)
+	case GitLab:
+		return fmt.Sprintf("https://%s/api/v4", host)
+	case Gitea:
+		return fmt.Sprintf("https://%s/api/v1", host)
+	default:
+		return ""
+	}
+}
+
+// Repository is a repository of a hosting provider.
+type Repository struct {
+	Host string
+	// Owner is the user or organization owning the repository. For GitLab, it includes the subgroups.
+	Owner string
+	Name  string
+}
+
+// Path returns the path of the repository on the host (e.g. "owner/name").
+func (r Repository) Path() string {
+	return r.Owner + "/" + r.Name
+}
+
+// ParseRemoteURL returns the Repository the given remote URL points to. Both the URL (e.g. https://github.com/o/r.git
+// or ssh://git@github.com/o/r.git) and the scp-like syntax (e.g. git@github.com:o/r.git) are supported.
+func ParseRemoteURL(remoteURL string) (Repository, error) {
+	var host, path string
+	if strings.Contains(remoteURL, "://") {
+		u, err := url.Parse(remoteURL)
+		if err != nil {
+			return Repository{}, fmt.Errorf("failed to parse remote URL %s: %w", remoteURL, err)
+		}
+		host, path = u.Hostname(), u.Path
+	} else if i := strings.Index(remoteURL, ":"); i >= 0 {
+		// scp-like syntax, optionally with a user (e.g. git@)
+		host, path = remoteURL[:i], remoteURL[i+1:]
+		if j := strings.LastIndex(host, "@"); j >= 0 {
+			host = host[j+1:]
+		}
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	i := strings.LastIndex(path, "/")
+	if len(host) == 0 || i <= 0 || i == len(path)-1 {
+		return Repository{}, fmt.Errorf("failed to parse remote URL %s: not a hosted repository", remoteURL)
+	}
+	return Repository{Host: host, Owner: path[:i], Name: path[i+1:]}, nil
+}
+
+// client makes the requests to the API of a provider.
+type client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// getJSON gets the given path, relative to the base URL, and decodes the JSON response into v.
+func (c *client) getJSON(ctx context.Context, path string, header http.Header, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request %s: %w", req.URL, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("failed to request %s: %s", req.URL, res.Status)
+	}
+	if err = json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", req.URL, err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package provider_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lopper/provider"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    provider.Repository
+		expectedErr bool
+	}{
+		{
+			name:     "HTTPS",
+			input:    "https://github.com/owner/name.git",
+			expected: provider.Repository{Host: "github.com", Owner: "owner", Name: "name"},
+		},
+		{
+			name:     "SSH",
+			input:    "ssh://git@gitea.example.com:2222/owner/name.git",
+			expected: provider.Repository{Host: "gitea.example.com", Owner: "owner", Name: "name"},
+		},
+		{
+			name:     "SCP-Like",
+			input:    "git@github.com:owner/name.git",
+			expected: provider.Repository{Host: "github.com", Owner: "owner", Name: "name"},
+		},
+		{
+			name:     "Subgroups",
+			input:    "https://gitlab.com/group/subgroup/name",
+			expected: provider.Repository{Host: "gitlab.com", Owner: "group/subgroup", Name: "name"},
+		},
+		{
+			name:        "Local Path",
+			input:       "/path/to/repo.git",
+			expectedErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := provider.ParseRemoteURL(test.input)
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGetOpenPullRequests(t *testing.T) {
+	tests := []struct {
+		name          string
+		kind          provider.Kind
+		repo          provider.Repository
+		expectedPath  string
+		expectedQuery string
+		expectedAuth  [2]string
+		response      string
+		expected      []provider.PullRequest
+	}{
+		{
+			name:          "GitHub",
+			kind:          provider.GitHub,
+			repo:          provider.Repository{Host: "github.com", Owner: "owner", Name: "name"},
+			expectedPath:  "/repos/owner/name/pulls",
+			expectedQuery: "direction=desc&page=1&per_page=100&sort=updated&state=open",
+			expectedAuth:  [2]string{"Authorization", "Bearer secret"},
+			response:      `[{"number": 7, "html_url": "https://github.com/owner/name/pull/7", "head": {"ref": "feature", "sha": "abc123", "repo": {"full_name": "owner/name"}}}, {"number": 8, "head": {"ref": "feature", "sha": "def456", "repo": {"full_name": "fork/name"}}}]`,
+			expected:      []provider.PullRequest{{Number: 7, Head: "feature", HeadSHA: "abc123", URL: "https://github.com/owner/name/pull/7"}},
+		},
+		{
+			name:          "GitLab",
+			kind:          provider.GitLab,
+			repo:          provider.Repository{Host: "gitlab.com", Owner: "group/subgroup", Name: "name"},
+			expectedPath:  "/projects/group%2Fsubgroup%2Fname/merge_requests",
+			expectedQuery: "page=1&per_page=100&state=opened",
+			expectedAuth:  [2]string{"PRIVATE-TOKEN", "secret"},
+			response: `[{"iid": 3, "web_url": "https://gitlab.com/group/subgroup/name/-/merge_requests/3", "source_branch": "feature", "source_project_id": 1, "target_project_id": 1, "sha": "abc123"}, ` +
+				`{"iid": 4, "source_branch": "feature", "source_project_id": 2, "target_project_id": 1, "sha": "def456"}]`,
+			expected: []provider.PullRequest{{Number: 3, Head: "feature", HeadSHA: "abc123", URL: "https://gitlab.com/group/subgroup/name/-/merge_requests/3"}},
+		},
+		{
+			name:          "Gitea",
+			kind:          provider.Gitea,
+			repo:          provider.Repository{Host: "gitea.com", Owner: "owner", Name: "name"},
+			expectedPath:  "/repos/owner/name/pulls",
+			expectedQuery: "limit=50&page=1&sort=recentupdate&state=open",
+			expectedAuth:  [2]string{"Authorization", "token secret"},
+			response: `[{"number": 5, "html_url": "https://gitea.com/owner/name/pulls/5", "head": {"ref": "feature", "sha": "abc123", "repo_id": 1}, "base": {"repo_id": 1}}, ` +
+				`{"number": 6, "head": {"ref": "other", "repo_id": 1}, "base": {"repo_id": 1}}, ` +
+				`{"number": 7, "head": {"ref": "feature", "sha": "def456", "repo_id": 2}, "base": {"repo_id": 1}}]`,
+			expected: []provider.PullRequest{{Number: 5, Head: "feature", HeadSHA: "abc123", URL: "https://gitea.com/owner/name/pulls/5"}, {Number: 6, Head: "other"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, test.expectedPath, r.URL.EscapedPath())
+				assert.Equal(t, test.expectedQuery, r.URL.RawQuery)
+				assert.Equal(t, test.expectedAuth[1], r.Header.Get(test.expectedAuth[0]))
+				_, _ = w.Write([]byte(test.response))
+			}))
+			defer server.Close()
+			p, err := provider.New(test.kind, test.repo.Host, server.URL, "secret")
+			require.NoError(t, err)
+			actual, err := p.GetOpenPullRequests(context.Background(), test.repo)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestGetOpenPullRequestsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	p, err := provider.New(provider.GitHub, "github.com", server.URL, "")
+	require.NoError(t, err)
+	_, err = p.GetOpenPullRequests(context.Background(), provider.Repository{Owner: "owner", Name: "name"})
+	assert.ErrorContains(t, err, "401 Unauthorized")
+}
+
+func TestGetMergedPullRequests(t *testing.T) {
+	since := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		kind          provider.Kind
+		repo          provider.Repository
+		expectedQuery string
+		response      string
+		expected      []provider.PullRequest
+	}{
+		{
+			name:          "GitHub",
+			kind:          provider.GitHub,
+			repo:          provider.Repository{Host: "github.com", Owner: "owner", Name: "name"},
+			expectedQuery: "direction=desc&page=1&per_page=100&sort=updated&state=closed",
+			response: `[{"number": 7, "updated_at": "2022-01-03T00:00:00Z", "merged_at": "2022-01-03T00:00:00Z", "head": {"ref": "feature", "sha": "abc123", "repo": {"full_name": "owner/name"}}}, ` +
+				`{"number": 8, "updated_at": "2022-01-02T00:00:00Z", "merged_at": null, "head": {"ref": "feature", "sha": "def456", "repo": {"full_name": "owner/name"}}}, ` +
+				`{"number": 9, "updated_at": "2021-12-01T00:00:00Z", "merged_at": "2021-12-01T00:00:00Z", "head": {"ref": "fix", "sha": "789abc", "repo": {"full_name": "owner/name"}}}]`,
+			expected: []provider.PullRequest{{Number: 7, Head: "feature", HeadSHA: "abc123"}},
+		},
+		{
+			name:          "GitLab",
+			kind:          provider.GitLab,
+			repo:          provider.Repository{Host: "gitlab.com", Owner: "owner", Name: "name"},
+			expectedQuery: "page=1&per_page=100&state=merged&updated_after=2022-01-01T00%3A00%3A00Z",
+			response: `[{"iid": 3, "source_branch": "feature", "source_project_id": 1, "target_project_id": 1, "sha": "abc123"}, ` +
+				`{"iid": 4, "source_branch": "feature", "source_project_id": 2, "target_project_id": 1, "sha": "def456"}]`,
+			expected: []provider.PullRequest{{Number: 3, Head: "feature", HeadSHA: "abc123"}},
+		},
+		{
+			name:          "Gitea",
+			kind:          provider.Gitea,
+			repo:          provider.Repository{Host: "gitea.com", Owner: "owner", Name: "name"},
+			expectedQuery: "limit=50&page=1&sort=recentupdate&state=closed",
+			response: `[{"number": 5, "updated_at": "2022-01-03T00:00:00Z", "merged": true, "head": {"ref": "feature", "sha": "abc123", "repo_id": 1}, "base": {"repo_id": 1}}, ` +
+				`{"number": 6, "updated_at": "2022-01-02T00:00:00Z", "merged": false, "head": {"ref": "feature", "sha": "def456", "repo_id": 1}, "base": {"repo_id": 1}}, ` +
+				`{"number": 7, "updated_at": "2022-01-02T00:00:00Z", "merged": true, "head": {"ref": "feature", "sha": "fed654", "repo_id": 2}, "base": {"repo_id": 1}}, ` +
+				`{"number": 4, "updated_at": "2021-12-01T00:00:00Z", "merged": true, "head": {"ref": "fix", "sha": "789abc", "repo_id": 1}, "base": {"repo_id": 1}}]`,
+			expected: []provider.PullRequest{{Number: 5, Head: "feature", HeadSHA: "abc123"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, test.expectedQuery, r.URL.RawQuery)
+				_, _ = w.Write([]byte(test.response))
+			}))
+			defer server.Close()
+			p, err := provider.New(test.kind, test.repo.Host, server.URL, "")
+			require.NoError(t, err)
+			actual, err := p.GetMergedPullRequests(context.Background(), test.repo, since)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestGetPullRequestsPages(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		// the first page is full, so the second page is requested as well
+		count := 50
+		if page != "1" {
+			count = 1
+		}
+		pullRequests := make([]string, 0, count)
+		for i := 0; i < count; i++ {
+			pullRequests = append(pullRequests, fmt.Sprintf(`{"number": %s%d, "head": {"ref": "feature"}}`, page, i))
+		}
+		_, _ = w.Write([]byte("[" + strings.Join(pullRequests, ",") + "]"))
+	}))
+	defer server.Close()
+	p, err := provider.New(provider.Gitea, "gitea.com", server.URL, "")
+	require.NoError(t, err)
+	actual, err := p.GetOpenPullRequests(context.Background(), provider.Repository{Owner: "owner", Name: "name"})
+	assert.NoError(t, err)
+	assert.Len(t, actual, 51)
+	assert.Equal(t, []string{"1", "2"}, pages)
+}
@@ -0,0 +1,55 @@
+// Package report defines the machine-readable report of a run, which is passed to the post-run hook and recorded in the
+// history.
+package report
+
+import "time"
+
+// Report is the machine-readable report of a run. It is passed as JSON to the post-run hook.
+type Report struct {
+	Paths        []string     `json:"paths"`
+	DryRun       bool         `json:"dryRun"`
+	StartTime    time.Time    `json:"startTime"`
+	EndTime      time.Time    `json:"endTime"`
+	Repositories []Repository `json:"repositories"`
+}
+
+// Repository is the outcome of processing a repository.
+type Repository struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// State is one of "pending", "in-progress", "completed", "error", "skipped", "paused" or "cancelled".
+	State string `json:"state"`
+	// Trunks are the branches the branches have been compared against (e.g. main or develop and main).
+	Trunks []string `json:"trunks,omitempty"`
+	// Clone is "shallow" or "partial" when the repository is not a full clone.
+	Clone      string          `json:"clone,omitempty"`
+	SkipReason string          `json:"skipReason,omitempty"`
+	Warnings   []string        `json:"warnings,omitempty"`
+	Errors     []string        `json:"errors,omitempty"`
+	Deleted    []Branch        `json:"deleted,omitempty"`
+	Skipped    []SkippedBranch `json:"skipped,omitempty"`
+	// DurationMS is the time taken to process the repository in milliseconds.
+	DurationMS int64 `json:"durationMs"`
+}
+
+// Branch is a branch that has been deleted, or would be deleted on a dry run.
+type Branch struct {
+	Name     string `json:"name"`
+	SHA      string `json:"sha"`
+	Strategy string `json:"strategy"`
+	Reason   string `json:"reason"`
+	// Trunk is the trunk branch the branch has been merged into, if it was detected against one.
+	Trunk string `json:"trunk,omitempty"`
+	// PullRequest is the number of the merged pull request the branch was detected with by the provider strategy.
+	PullRequest int `json:"pullRequest,omitempty"`
+	// ReducedConfidence is true when the branch was detected without the full history of the repository.
+	ReducedConfidence bool `json:"reducedConfidence,omitempty"`
+	// PrunedRemote is the remote the matching branch has been deleted on as well, if any.
+	PrunedRemote string `json:"prunedRemote,omitempty"`
+}
+
+// SkippedBranch is a merged branch that was not deleted.
+type SkippedBranch struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
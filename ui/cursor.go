@@ -0,0 +1,42 @@
+package ui
+
+// moveCursor moves the cursor over the repositories by the given delta and scrolls the viewport to keep the selected
+// repository visible.
+func (m *Model) moveCursor(delta int) {
+	if len(m.repositories) == 0 {
+		return
+	}
+	// skip over the repositories hidden by the filter
+	for range m.repositories {
+		m.cursor = (m.cursor + delta + len(m.repositories)) % len(m.repositories)
+		if m.isVisible(m.cursor) {
+			break
+		}
+	}
+	// the cursor is only rendered in the list of repositories
+	m.showSummary = false
+	m.followCursor()
+}
+
+// selectVisible moves the cursor to the next visible repository when the selected repository has been hidden by the
+// filter.
+func (m *Model) selectVisible() {
+	if len(m.repositories) == 0 || m.isVisible(m.cursor) {
+		return
+	}
+	m.moveCursor(1)
+}
+
+// followCursor scrolls the viewport so the line of the selected repository is visible. The lines of the repositories
+// are tracked when the body is rendered.
+func (m *Model) followCursor() {
+	if m.cursor >= len(m.repoLines) || m.repoLines[m.cursor] < 0 {
+		return
+	}
+	line := m.repoLines[m.cursor]
+	if line < m.viewport.YOffset {
+		m.viewport.SetYOffset(line)
+	} else if line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(line - m.viewport.Height + 1)
+	}
+}
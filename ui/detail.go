@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"lopper/git"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxStderrLines is the number of lines of the standard error of a failed command shown in the detail pane.
+const maxStderrLines = 5
+
+// commandLog records the Git commands executed against each repository. Commands are recorded concurrently while the
+// repositories are processed.
+type commandLog struct {
+	mu       sync.Mutex
+	commands map[string][]git.Command
+}
+
+// record records the given Git command against the repository it was executed against.
+func (l *commandLog) record(command git.Command) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.commands[command.Dir] = append(l.commands[command.Dir], command)
+}
+
+// get returns the Git commands executed against the given repository.
+func (l *commandLog) get(repo git.Repository) []git.Command {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]git.Command(nil), l.commands[filepath.Join(repo.Path, repo.Name)]...)
+}
+
+func (l *commandLog) clear(repo git.Repository) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.commands, filepath.Join(repo.Path, repo.Name))
+}
+
+// resize splits the width of the window between the list of repositories and the detail pane.
+func (m *Model) resize() {
+	if m.showDetail {
+		m.viewport.Width = m.width / 2
+		m.detail.Width = m.width - m.viewport.Width
+	} else {
+		m.viewport.Width = m.width
+	}
+}
+
+// getDetail returns the details of the selected repository.
+func getDetail(m *Model) string {
+	if m.cursor >= len(m.repositories) {
+		return ""
+	}
+	var b strings.Builder
+	r := m.repositories[m.cursor]
+	b.WriteString(fmt.Sprintf("%s\n", r.Name))
+	b.WriteString(m.styles.muted.Render(filepath.Join(r.Path, r.Name)) + "\n\n")
+	if trunks := m.repoTrunks[m.cursor]; len(trunks) > 0 {
+		b.WriteString(fmt.Sprintf("Trunk - %s\n", strings.Join(trunks, ", ")))
+	}
+	if c := m.clones[m.cursor]; c != fullClone {
+		b.WriteString(fmt.Sprintf("Clone - %s\n", c))
+	}
+	if duration, ok := m.durations[m.cursor]; ok {
+		b.WriteString(fmt.Sprintf("Time Taken - %s\n", duration.Round(time.Millisecond)))
+	}
+	if reason := m.skipReasons[m.cursor]; len(reason) > 0 {
+		b.WriteString(fmt.Sprintf("Skipped - %s\n", reason))
+	}
+	for _, warning := range m.warnings[m.cursor] {
+		b.WriteString(fmt.Sprintf("Warning - %s\n", warning))
+	}
+
+	if branches := m.deletedBranches[m.cursor]; len(branches) > 0 {
+		b.WriteString("\nBranches\n")
+		for _, branch := range branches {
+			b.WriteString(fmt.Sprintf("%s %s\n", branch.name, m.styles.muted.Render(shortSHA(branch.commit.SHA))))
+			b.WriteString(m.styles.muted.Render(fmt.Sprintf("   %s", branch.commit.Subject)) + "\n")
+			b.WriteString(m.styles.muted.Render(fmt.Sprintf("   by %s", branch.commit.Author)) + "\n")
+			b.WriteString(m.styles.muted.Render(fmt.Sprintf("   %s: %s", branch.strategy, branch.reason)) + "\n")
+		}
+	}
+	if skipped := m.skippedBranches[m.cursor]; len(skipped) > 0 {
+		b.WriteString("\nSkipped Branches\n")
+		for _, branch := range skipped {
+			b.WriteString(fmt.Sprintf("%s\n", branch.name))
+			b.WriteString(m.styles.muted.Render(fmt.Sprintf("   %s", branch.reason)) + "\n")
+		}
+	}
+
+	if commands := m.commands.get(r); len(commands) > 0 {
+		b.WriteString("\nCommands\n")
+		for _, command := range commands {
+			symbol := m.styles.completed.Render(m.theme.Symbols.Completed)
+			if command.Failed() {
+				symbol = m.styles.error.Render(m.theme.Symbols.Error)
+			}
+			b.WriteString(fmt.Sprintf(
+				"%s git %s %s\n",
+				symbol,
+				strings.Join(command.Args, " "),
+				m.styles.muted.Render(fmt.Sprintf("(%s, exit %d)", command.Duration.Round(time.Millisecond), command.ExitCode)),
+			))
+			if command.Failed() {
+				for _, line := range getStderrLines(command.Stderr) {
+					b.WriteString(m.styles.error.Render(fmt.Sprintf("   %s", line)) + "\n")
+				}
+			}
+		}
+	}
+	// wrap the lines so long commands and errors are not cut off by the pane
+	return lipgloss.NewStyle().Width(m.detail.Width - m.styles.detail.GetHorizontalFrameSize()).Render(b.String())
+}
+
+func getStderrLines(stderr string) []string {
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	if len(lines) > maxStderrLines {
+		lines = append(lines[:maxStderrLines], "...")
+	}
+	return lines
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
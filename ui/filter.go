@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"strings"
+)
+
+// filter narrows down the repositories and branches rendered in the body. The header counts are not affected by the
+// filter.
+type filter struct {
+	// search is the input of the incremental search of repository and branch names.
+	search        textinput.Model
+	onlyFailed    bool
+	onlyDeleted   bool
+	hideUntouched bool
+}
+
+func newSearch() textinput.Model {
+	t := textinput.New()
+	t.Prompt = "/"
+	t.Placeholder = "search repositories and branches"
+	return t
+}
+
+// updateSearch handles the key presses while the search is focused.
+func (m *Model) updateSearch(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "enter":
+		m.filter.search.Blur()
+		return nil
+	case "esc":
+		m.filter.search.Blur()
+		m.filter.search.SetValue("")
+		return nil
+	}
+	var cmd tea.Cmd
+	m.filter.search, cmd = m.filter.search.Update(msg)
+	m.selectVisible()
+	return cmd
+}
+
+// isFiltered returns true if any filter is applied.
+func (m *Model) isFiltered() bool {
+	return len(m.filter.search.Value()) > 0 || m.filter.onlyFailed || m.filter.onlyDeleted || m.filter.hideUntouched
+}
+
+// clearFilter removes all filters.
+func (m *Model) clearFilter() {
+	m.filter.search.SetValue("")
+	m.filter.onlyFailed = false
+	m.filter.onlyDeleted = false
+	m.filter.hideUntouched = false
+}
+
+// isVisible returns true if the repository at the given position passes the filter.
+func (m *Model) isVisible(position int) bool {
+	if m.filter.onlyFailed && m.states[position] != errorState {
+		return false
+	}
+	if m.filter.onlyDeleted && len(m.deletedBranches[position]) == 0 {
+		return false
+	}
+	if m.filter.hideUntouched && m.isUntouched(position) {
+		return false
+	}
+	if m.matchesSearch(m.repositories[position].Name) {
+		return true
+	}
+	return len(m.getVisibleDeletedBranches(position)) > 0 || len(m.getVisibleSkippedBranches(position)) > 0
+}
+
+// isUntouched returns true if the repository at the given position has been processed without anything to show for
+// it.
+func (m *Model) isUntouched(position int) bool {
+	return m.states[position] == completedState &&
+		len(m.deletedBranches[position]) == 0 &&
+		len(m.skippedBranches[position]) == 0
+}
+
+func (m *Model) matchesSearch(name string) bool {
+	return strings.Contains(strings.ToLower(name), strings.ToLower(m.filter.search.Value()))
+}
+
+// getVisibleDeletedBranches returns the deleted branches of the repository at the given position that pass the
+// search. All branches are visible when the name of the repository matches the search.
+func (m *Model) getVisibleDeletedBranches(position int) []deletedBranch {
+	if m.matchesSearch(m.repositories[position].Name) {
+		return m.deletedBranches[position]
+	}
+	var branches []deletedBranch
+	for _, branch := range m.deletedBranches[position] {
+		if m.matchesSearch(branch.name) {
+			branches = append(branches, branch)
+		}
+	}
+	return branches
+}
+
+// getVisibleSkippedBranches returns the skipped branches of the repository at the given position that pass the
+// search. All branches are visible when the name of the repository matches the search.
+func (m *Model) getVisibleSkippedBranches(position int) []skippedBranch {
+	if m.matchesSearch(m.repositories[position].Name) {
+		return m.skippedBranches[position]
+	}
+	var branches []skippedBranch
+	for _, branch := range m.skippedBranches[position] {
+		if m.matchesSearch(branch.name) {
+			branches = append(branches, branch)
+		}
+	}
+	return branches
+}
+
+// getFilterStatus returns a description of the applied filters.
+func (m *Model) getFilterStatus() string {
+	var status []string
+	if m.filter.search.Focused() || len(m.filter.search.Value()) > 0 {
+		status = append(status, m.filter.search.View())
+	}
+	if m.filter.onlyFailed {
+		status = append(status, "[only failed]")
+	}
+	if m.filter.onlyDeleted {
+		status = append(status, "[only deletions]")
+	}
+	if m.filter.hideUntouched {
+		status = append(status, "[hide untouched]")
+	}
+	return strings.Join(status, " ")
+}
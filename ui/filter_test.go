@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"errors"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"lopper/git"
+	"testing"
+)
+
+// newFilterModel returns a Model with a deleting, a failed, an untouched and a skipping repository.
+func newFilterModel() *Model {
+	m := NewModel()
+	m.repositories = []git.Repository{{Name: "api"}, {Name: "web"}, {Name: "docs"}, {Name: "cli"}}
+	m.states[0] = completedState
+	m.deletedBranches[0] = []deletedBranch{{name: "feature"}, {name: "fix-login"}}
+	m.states[1] = errorState
+	m.errMessages[1] = []error{errors.New("failed to delete branch")}
+	m.states[2] = completedState
+	m.states[3] = completedState
+	m.skippedBranches[3] = []skippedBranch{{name: "release", reason: "protected"}}
+	return m
+}
+
+func TestIsVisible(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   func(f *filter)
+		expected []int
+	}{
+		{
+			name:     "No Filter",
+			filter:   func(f *filter) {},
+			expected: []int{0, 1, 2, 3},
+		},
+		{
+			name:     "Only Failed",
+			filter:   func(f *filter) { f.onlyFailed = true },
+			expected: []int{1},
+		},
+		{
+			name:     "Only Deleted",
+			filter:   func(f *filter) { f.onlyDeleted = true },
+			expected: []int{0},
+		},
+		{
+			name:     "Hide Untouched",
+			filter:   func(f *filter) { f.hideUntouched = true },
+			expected: []int{0, 1, 3},
+		},
+		{
+			name:     "Search Repository",
+			filter:   func(f *filter) { f.search.SetValue("WE") },
+			expected: []int{1},
+		},
+		{
+			name:     "Search Deleted Branch",
+			filter:   func(f *filter) { f.search.SetValue("login") },
+			expected: []int{0},
+		},
+		{
+			name:     "Search Skipped Branch",
+			filter:   func(f *filter) { f.search.SetValue("release") },
+			expected: []int{3},
+		},
+		{
+			name: "Search And Only Failed",
+			filter: func(f *filter) {
+				f.search.SetValue("login")
+				f.onlyFailed = true
+			},
+			expected: []int{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := newFilterModel()
+			test.filter(&m.filter)
+			visible := []int{}
+			for i := range m.repositories {
+				if m.isVisible(i) {
+					visible = append(visible, i)
+				}
+			}
+			assert.Equal(t, test.expected, visible)
+		})
+	}
+}
+
+func TestGetVisibleBranches(t *testing.T) {
+	m := newFilterModel()
+
+	// only the matching branches are visible
+	m.filter.search.SetValue("fix")
+	assert.Equal(t, []deletedBranch{{name: "fix-login"}}, m.getVisibleDeletedBranches(0))
+	assert.Empty(t, m.getVisibleSkippedBranches(3))
+
+	// all branches are visible when the repository matches
+	m.filter.search.SetValue("api")
+	assert.Equal(t, m.deletedBranches[0], m.getVisibleDeletedBranches(0))
+	m.filter.search.SetValue("cli")
+	assert.Equal(t, m.skippedBranches[3], m.getVisibleSkippedBranches(3))
+}
+
+func TestUpdateSearch(t *testing.T) {
+	m := newFilterModel()
+	m.filter.search.Focus()
+	for _, r := range "web" {
+		m.updateSearch(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	assert.Equal(t, "web", m.filter.search.Value())
+	assert.True(t, m.isFiltered())
+
+	// enter keeps the search applied
+	m.updateSearch(tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, m.filter.search.Focused())
+	assert.Equal(t, "web", m.filter.search.Value())
+
+	// esc removes the search
+	m.filter.search.Focus()
+	m.updateSearch(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.False(t, m.filter.search.Focused())
+	assert.Empty(t, m.filter.search.Value())
+	assert.False(t, m.isFiltered())
+}
+
+func TestClearFilter(t *testing.T) {
+	m := newFilterModel()
+	m.filter.search.SetValue("web")
+	m.filter.onlyFailed = true
+	m.filter.onlyDeleted = true
+	m.filter.hideUntouched = true
+	// the search is rendered with its cursor
+	assert.Contains(t, m.getFilterStatus(), "/web")
+	assert.Contains(t, m.getFilterStatus(), " [only failed] [only deletions] [hide untouched]")
+
+	m.clearFilter()
+	assert.False(t, m.isFiltered())
+	assert.Empty(t, m.getFilterStatus())
+}
@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/key"
+	"sort"
+	"strings"
+)
+
+// KeyMap are the key bindings of the TUI. 'ctrl+c' always quits, regardless of the key bindings.
+type KeyMap struct {
+	Up                  key.Binding
+	Down                key.Binding
+	PageUp              key.Binding
+	PageDown            key.Binding
+	Home                key.Binding
+	End                 key.Binding
+	Next                key.Binding
+	Previous            key.Binding
+	Detail              key.Binding
+	DetailUp            key.Binding
+	DetailDown          key.Binding
+	Retry               key.Binding
+	RetryAll            key.Binding
+	Search              key.Binding
+	OnlyFailed          key.Binding
+	OnlyDeleted         key.Binding
+	HideUntouched       key.Binding
+	ClearFilter         key.Binding
+	Pause               key.Binding
+	Cancel              key.Binding
+	IncreaseConcurrency key.Binding
+	DecreaseConcurrency key.Binding
+	Summary             key.Binding
+	Help                key.Binding
+	Quit                key.Binding
+}
+
+// DefaultKeyMap returns the default key bindings of the TUI.
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Up:                  newBinding("scroll up", "up", "k"),
+		Down:                newBinding("scroll down", "down", "j"),
+		PageUp:              newBinding("page up", "pgup"),
+		PageDown:            newBinding("page down", "pgdown"),
+		Home:                newBinding("go to top", "home", "g"),
+		End:                 newBinding("go to bottom", "end", "G"),
+		Next:                newBinding("select next", "tab"),
+		Previous:            newBinding("select previous", "shift+tab"),
+		Detail:              newBinding("toggle details", "enter"),
+		DetailUp:            newBinding("scroll details up", "shift+up"),
+		DetailDown:          newBinding("scroll details down", "shift+down"),
+		Retry:               newBinding("retry selected", "r"),
+		RetryAll:            newBinding("retry all failed", "R"),
+		Search:              newBinding("search", "/"),
+		OnlyFailed:          newBinding("only failed", "f"),
+		OnlyDeleted:         newBinding("only deletions", "d"),
+		HideUntouched:       newBinding("hide untouched", "u"),
+		ClearFilter:         newBinding("clear filters", "esc"),
+		Pause:               newBinding("pause/resume", "p"),
+		Cancel:              newBinding("cancel selected", "x"),
+		IncreaseConcurrency: newBinding("more concurrency", "+"),
+		DecreaseConcurrency: newBinding("less concurrency", "-"),
+		Summary:             newBinding("toggle summary", "s"),
+		Help:                newBinding("toggle help", "?"),
+		Quit:                newBinding("quit", "q"),
+	}
+}
+
+func newBinding(description string, keys ...string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(strings.Join(keys, "/"), description))
+}
+
+// NewKeyMap returns the default key bindings with the actions in the given bindings rebound to the given keys.
+//
+// The actions are named in kebab case after the fields of KeyMap (e.g. "page-up" for KeyMap.PageUp).
+func NewKeyMap(bindings map[string][]string) (KeyMap, error) {
+	keyMap := DefaultKeyMap()
+	actions := keyMap.actions()
+	for action, keys := range bindings {
+		binding, ok := actions[action]
+		if !ok {
+			return keyMap, fmt.Errorf("unknown action %s, must be one of %s", action, strings.Join(getActionNames(actions), ", "))
+		}
+		if len(keys) == 0 {
+			return keyMap, fmt.Errorf("no keys bound to action %s", action)
+		}
+		binding.SetKeys(keys...)
+		binding.SetHelp(strings.Join(keys, "/"), binding.Help().Desc)
+	}
+	return keyMap, nil
+}
+
+// actions returns the bindings of the KeyMap keyed by the name of their action.
+func (k *KeyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":                   &k.Up,
+		"down":                 &k.Down,
+		"page-up":              &k.PageUp,
+		"page-down":            &k.PageDown,
+		"home":                 &k.Home,
+		"end":                  &k.End,
+		"next":                 &k.Next,
+		"previous":             &k.Previous,
+		"detail":               &k.Detail,
+		"detail-up":            &k.DetailUp,
+		"detail-down":          &k.DetailDown,
+		"retry":                &k.Retry,
+		"retry-all":            &k.RetryAll,
+		"search":               &k.Search,
+		"only-failed":          &k.OnlyFailed,
+		"only-deleted":         &k.OnlyDeleted,
+		"hide-untouched":       &k.HideUntouched,
+		"clear-filter":         &k.ClearFilter,
+		"pause":                &k.Pause,
+		"cancel":               &k.Cancel,
+		"increase-concurrency": &k.IncreaseConcurrency,
+		"decrease-concurrency": &k.DecreaseConcurrency,
+		"summary":              &k.Summary,
+		"help":                 &k.Help,
+		"quit":                 &k.Quit,
+	}
+}
+
+func getActionNames(actions map[string]*key.Binding) []string {
+	var names []string
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ShortHelp returns the key bindings hinted at in the footer. It is part of the help.KeyMap interface.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Next, k.Detail, k.Search, k.Help, k.Quit}
+}
+
+// FullHelp returns the key bindings shown in the help overlay, grouped in columns. It is part of the help.KeyMap
+// interface.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown, k.Home, k.End, k.DetailUp, k.DetailDown},
+		{k.Next, k.Previous, k.Detail, k.Retry, k.RetryAll, k.Summary},
+		{k.Search, k.OnlyFailed, k.OnlyDeleted, k.HideUntouched, k.ClearFilter},
+		{k.Pause, k.Cancel, k.IncreaseConcurrency, k.DecreaseConcurrency, k.Help, k.Quit},
+	}
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewKeyMap(t *testing.T) {
+	tests := []struct {
+		name        string
+		bindings    map[string][]string
+		expected    func() KeyMap
+		expectedErr bool
+	}{
+		{
+			name:     "Default",
+			expected: DefaultKeyMap,
+		},
+		{
+			name:     "Rebind",
+			bindings: map[string][]string{"page-down": {"space", "ctrl+d"}},
+			expected: func() KeyMap {
+				k := DefaultKeyMap()
+				k.PageDown = key.NewBinding(key.WithKeys("space", "ctrl+d"), key.WithHelp("space/ctrl+d", "page down"))
+				return k
+			},
+		},
+		{
+			name:        "Unknown Action",
+			bindings:    map[string][]string{"foo": {"f"}},
+			expectedErr: true,
+		},
+		{
+			name:        "No Keys",
+			bindings:    map[string][]string{"up": {}},
+			expectedErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := NewKeyMap(test.bindings)
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected(), actual)
+			}
+		})
+	}
+}
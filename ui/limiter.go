@@ -0,0 +1,65 @@
+package ui
+
+import "sync"
+
+// limiter limits the number of repositories that are processed concurrently. Unlike a semaphore, the limit can be
+// changed and acquiring can be paused while the repositories are processed.
+type limiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+	paused bool
+}
+
+// newLimiter returns a limiter of the given number of repositories. The limit is at least 1.
+func newLimiter(limit int) *limiter {
+	l := &limiter{}
+	l.cond = sync.NewCond(&l.mu)
+	l.setLimit(limit)
+	return l
+}
+
+// acquire blocks until a repository can be processed.
+func (l *limiter) acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.paused || l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+}
+
+// release allows the next repository to be processed.
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	l.cond.Broadcast()
+}
+
+// setLimit changes the number of repositories that can be processed concurrently. The limit is at least 1.
+func (l *limiter) setLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limit < 1 {
+		limit = 1
+	}
+	l.limit = limit
+	l.cond.Broadcast()
+}
+
+// getLimit returns the number of repositories that can be processed concurrently.
+func (l *limiter) getLimit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// setPaused pauses or resumes acquiring. Repositories that are already being processed are not affected.
+func (l *limiter) setPaused(paused bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.paused = paused
+	l.cond.Broadcast()
+}
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(1)
+	l.acquire()
+
+	acquired := make(chan struct{})
+	go func() {
+		l.acquire()
+		close(acquired)
+	}()
+	assertBlocked(t, acquired)
+
+	// raising the limit allows the waiting acquire to continue
+	l.setLimit(2)
+	assertAcquired(t, acquired)
+
+	// pausing blocks acquiring even when below the limit
+	l.release()
+	l.setPaused(true)
+	acquired = make(chan struct{})
+	go func() {
+		l.acquire()
+		close(acquired)
+	}()
+	assertBlocked(t, acquired)
+	l.setPaused(false)
+	assertAcquired(t, acquired)
+
+	// the limit is at least 1
+	l.setLimit(0)
+	assert.Equal(t, 1, l.getLimit())
+}
+
+func assertBlocked(t *testing.T, acquired chan struct{}) {
+	select {
+	case <-acquired:
+		t.Fatal("expected acquire to block")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func assertAcquired(t *testing.T, acquired chan struct{}) {
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("expected acquire to continue")
+	}
+}
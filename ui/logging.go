@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"errors"
+	"log/slog"
+	"path/filepath"
+)
+
+// logCompleted logs the outcome of processing the repository of the given message.
+func (m *Model) logCompleted(msg completedMsg) {
+	r := m.repositories[msg.position]
+	logger := m.logger.With(slog.String("repository", filepath.Join(r.Path, r.Name)))
+	for _, branch := range msg.branches {
+		logger.Info(
+			"deleted branch",
+			slog.String("branch", branch.name),
+			slog.String("sha", branch.commit.SHA),
+			slog.String("strategy", string(branch.strategy)),
+			slog.String("reason", branch.reason),
+			slog.String("trunk", branch.trunk),
+			slog.Bool("reducedConfidence", branch.reducedConfidence),
+			slog.Bool("dryRun", m.dryRun),
+		)
+	}
+	for _, skipped := range msg.skipped {
+		logger.Info("skipped branch", slog.String("branch", skipped.name), slog.String("reason", skipped.reason))
+	}
+	for _, warning := range msg.warnings {
+		logger.Warn("incomplete detection", slog.String("warning", warning))
+	}
+	for _, err := range msg.errs {
+		var processErr processError
+		if errors.As(err, &processErr) {
+			logger.Error("failed to process repository", slog.String("type", string(processErr.errorType)), slog.String("error", err.Error()))
+		} else {
+			logger.Error("failed to process repository", slog.String("error", err.Error()))
+		}
+	}
+	logger.Info(
+		"processed repository",
+		slog.String("state", stateNames[m.states[msg.position]]),
+		slog.String("skipReason", msg.skipReason),
+		slog.Duration("duration", msg.duration),
+	)
+}
@@ -1,6 +1,10 @@
 package ui
 
-import "lopper/git"
+import (
+	"fmt"
+	"lopper/git"
+	"time"
+)
 
 // errorMsg is a tea.Msg that communicates an error.
 type errorMsg struct {
@@ -10,6 +14,8 @@ type errorMsg struct {
 // repositoriesMsg is a tea.Msg that communicates a list of git.Repository to be processed.
 type repositoriesMsg struct {
 	repositories []git.Repository
+	// warnings are the problems that did not prevent the repositories from being discovered.
+	warnings []error
 }
 
 // inprocessMsg is a tea.Msg that communicates a git.Repository that is currently being processed.
@@ -21,6 +27,109 @@ type inprocessMsg struct {
 // completedMsg is a tea.Msg that communicates a git.Repository that has been processed.
 type completedMsg struct {
 	position int
-	branches []string
+	processResult
+}
+
+// processResult is the outcome of processing a git.Repository.
+type processResult struct {
+	// trunks are the resolved trunk branches of the repository (e.g. main or develop and main).
+	trunks   []string
+	clone    clone
+	branches []deletedBranch
+	skipped  []skippedBranch
+	// skipReason is the reason the whole repository was skipped, if it was skipped.
+	skipReason string
+	// warnings are the problems that did not fail the repository, but may have left out merged branches.
+	warnings []string
 	errs     []error
+	duration time.Duration
+}
+
+// deletedBranch is a branch that has been deleted, or would be deleted on a dry run.
+type deletedBranch struct {
+	name     string
+	strategy strategy
+	// reason explains why the branch was detected as merged.
+	reason string
+	// trunk is the trunk branch the branch has been merged into, if it was detected against one.
+	trunk  string
+	commit git.Commit
+	// pullRequest is the number of the merged pull request the branch was detected with by the providerStrategy.
+	pullRequest int
+	// reducedConfidence is true when the branch was detected without the full history of the repository.
+	reducedConfidence bool
+	// prunedRemote is the remote the matching branch has been deleted on as well, if any.
+	prunedRemote string
+}
+
+func (d deletedBranch) String() string {
+	name := d.name
+	if d.pullRequest > 0 {
+		name = fmt.Sprintf("%s (#%d)", name, d.pullRequest)
+	}
+	if d.reducedConfidence {
+		name = fmt.Sprintf("%s (reduced confidence)", name)
+	}
+	if len(d.prunedRemote) > 0 {
+		name = fmt.Sprintf("%s (also on %s)", name, d.prunedRemote)
+	}
+	return name
+}
+
+// strategy is how a branch was detected as merged.
+type strategy string
+
+const (
+	mergedStrategy   strategy = "merged"
+	squashedStrategy strategy = "squashed"
+	providerStrategy strategy = "provider"
+	// upstreamGoneStrategy is only used when the history of a shallow clone is not enough for the other strategies.
+	upstreamGoneStrategy strategy = "upstream-gone"
+)
+
+// clone is the kind of clone of a repository.
+type clone string
+
+const (
+	fullClone clone = ""
+	// shallowClone is missing the history before a depth or date.
+	shallowClone clone = "shallow"
+	// partialClone is missing objects, which are fetched when they are needed.
+	partialClone clone = "partial"
+)
+
+// skippedBranch is a merged branch that was not deleted.
+type skippedBranch struct {
+	name   string
+	reason string
+}
+
+func (s skippedBranch) String() string {
+	return fmt.Sprintf("%s (skipped: %s)", s.name, s.reason)
+}
+
+// errorType categorizes the errors that occur while processing a git.Repository, so they can be grouped.
+type errorType string
+
+const (
+	updateErrorType    errorType = "Update"
+	detectionErrorType errorType = "Detection"
+	worktreeErrorType  errorType = "Worktree"
+	deleteErrorType    errorType = "Delete"
+	hookErrorType      errorType = "Hook"
+	providerErrorType  errorType = "Provider"
+)
+
+// processError is an error that occurred while processing a git.Repository.
+type processError struct {
+	errorType errorType
+	err       error
+}
+
+func (e processError) Error() string {
+	return e.err.Error()
+}
+
+func (e processError) Unwrap() error {
+	return e.err
 }
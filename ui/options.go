@@ -1,16 +1,28 @@
 package ui
 
 import (
-	"golang.org/x/sync/semaphore"
+	"io"
+	"log/slog"
+	"lopper/config"
+	"lopper/discovery"
+	"lopper/provider"
 )
 
 // Option is a function that is used to update the Model.
 type Option func(m *Model)
 
-// Path sets the path of the file or directory to be operated on.
-func Path(path string) Option {
+// Paths sets the paths of the repositories, or directories containing repositories, to be operated on.
+func Paths(paths []string) Option {
 	return func(m *Model) {
-		m.path = path
+		m.paths = paths
+	}
+}
+
+// ManifestProjects sets the projects of a workspace manifest to be operated on, along with the paths. The declared
+// default branch of a project is its trunk, unless trunk branches have been configured for it.
+func ManifestProjects(projects []discovery.Project) Option {
+	return func(m *Model) {
+		m.projects = projects
 	}
 }
 
@@ -24,9 +36,13 @@ func ProtectedBranches(protectedBranches []string) Option {
 // Concurrency sets the number of repositories to be processed in parallel.
 func Concurrency(concurrency int) Option {
 	return func(m *Model) {
+		// at least one repository has to be processed at a time, or the processing never starts
+		if concurrency < 1 {
+			concurrency = 1
+		}
 		m.startProcessMsgs = make(chan inprocessMsg, concurrency)
 		m.completedMsgs = make(chan completedMsg, concurrency)
-		m.semaphore = semaphore.NewWeighted(int64(concurrency))
+		m.limiter = newLimiter(concurrency)
 	}
 }
 
@@ -36,3 +52,113 @@ func DryRun(dryRun bool) Option {
 		m.dryRun = dryRun
 	}
 }
+
+// PruneWorktrees removes clean linked worktrees that have a merged branch checked out so the branch can be deleted.
+func PruneWorktrees(pruneWorktrees bool) Option {
+	return func(m *Model) {
+		m.pruneWorktrees = pruneWorktrees
+	}
+}
+
+// RecurseSubmodules processes the initialized submodules of each repository as well.
+func RecurseSubmodules(recurseSubmodules bool) Option {
+	return func(m *Model) {
+		m.recurseSubmodules = recurseSubmodules
+	}
+}
+
+// TrunkRemote compares the branches against the main branch of the given remote (e.g. upstream), instead of the local
+// main branch, which may track a fork.
+func TrunkRemote(remote string) Option {
+	return func(m *Model) {
+		m.trunkRemote = remote
+	}
+}
+
+// Trunks compares the branches of the matching repositories against each of the given trunk branches (e.g. develop
+// and main), instead of only the main branch. The first matching config.Trunk is used.
+func Trunks(trunks []config.Trunk) Option {
+	return func(m *Model) {
+		m.trunks = trunks
+	}
+}
+
+// PruneRemote deletes the matching branches on the given remote (e.g. the fork) along with the local branches.
+func PruneRemote(remote string) Option {
+	return func(m *Model) {
+		m.pruneRemote = remote
+	}
+}
+
+// Deepen fetches the given number of commits of history into shallow clones before detecting the merged branches.
+func Deepen(depth int) Option {
+	return func(m *Model) {
+		m.deepen = depth
+	}
+}
+
+// ShallowSince fetches the history since the given date into shallow clones before detecting the merged branches.
+func ShallowSince(date string) Option {
+	return func(m *Model) {
+		m.shallowSince = date
+	}
+}
+
+// DeleteGoneBranches deletes the branches of shallow clones whose upstream branch has been deleted from the remote.
+// They are skipped otherwise, since the upstream branch may have been deleted without merging it.
+func DeleteGoneBranches(deleteGoneBranches bool) Option {
+	return func(m *Model) {
+		m.deleteGoneBranches = deleteGoneBranches
+	}
+}
+
+// PreDeleteHook runs the given command before a branch is deleted. A non-zero exit code of the command skips the
+// branch.
+func PreDeleteHook(command string) Option {
+	return func(m *Model) {
+		m.preDeleteHook = command
+	}
+}
+
+// PostDeleteHook runs the given command after a branch has been deleted.
+func PostDeleteHook(command string) Option {
+	return func(m *Model) {
+		m.postDeleteHook = command
+	}
+}
+
+// Providers protects branches with open pull requests, by asking the hosting providers of the repositories. The
+// providers are keyed by the host of the remote URLs of their repositories.
+func Providers(providers map[string]provider.Provider) Option {
+	return func(m *Model) {
+		m.providers = providers
+	}
+}
+
+// Logger logs the outcome of processing the repositories to the given logger.
+func Logger(logger *slog.Logger) Option {
+	return func(m *Model) {
+		m.logger = logger
+	}
+}
+
+// Output prints the progress as plain lines of text to the given writer instead of rendering the TUI.
+func Output(output io.Writer) Option {
+	return func(m *Model) {
+		m.output = output
+	}
+}
+
+// Styles renders the UI with the colors and symbols of the given Theme.
+func Styles(theme Theme) Option {
+	return func(m *Model) {
+		m.theme = theme
+	}
+}
+
+// Keys binds the actions of the TUI to the keys of the given KeyMap.
+func Keys(keyMap KeyMap) Option {
+	return func(m *Model) {
+		m.keys = keyMap
+	}
+}
@@ -2,6 +2,11 @@ package ui
 
 import (
 	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"lopper/config"
+	"lopper/discovery"
+	"lopper/provider"
+	"os"
 	"testing"
 )
 
@@ -12,10 +17,17 @@ func TestOptions(t *testing.T) {
 		expected Model
 	}{
 		{
-			name:   "Path",
-			option: Path("/path/to/file"),
+			name:   "Paths",
+			option: Paths([]string{"/path/to/file", "/path/to/other"}),
 			expected: Model{
-				path: "/path/to/file",
+				paths: []string{"/path/to/file", "/path/to/other"},
+			},
+		},
+		{
+			name:   "Manifest Projects",
+			option: ManifestProjects([]discovery.Project{{Path: "/workspace/api", Trunk: "develop"}}),
+			expected: Model{
+				projects: []discovery.Project{{Path: "/workspace/api", Trunk: "develop"}},
 			},
 		},
 		{
@@ -32,6 +44,111 @@ func TestOptions(t *testing.T) {
 				dryRun: true,
 			},
 		},
+		{
+			name:   "Prune Worktrees",
+			option: PruneWorktrees(true),
+			expected: Model{
+				pruneWorktrees: true,
+			},
+		},
+		{
+			name:   "Recurse Submodules",
+			option: RecurseSubmodules(true),
+			expected: Model{
+				recurseSubmodules: true,
+			},
+		},
+		{
+			name:   "Output",
+			option: Output(os.Stdout),
+			expected: Model{
+				output: os.Stdout,
+			},
+		},
+		{
+			name:   "Trunk Remote",
+			option: TrunkRemote("upstream"),
+			expected: Model{
+				trunkRemote: "upstream",
+			},
+		},
+		{
+			name:   "Trunks",
+			option: Trunks([]config.Trunk{{Repositories: "release-*", Branches: []string{"develop", "main"}}}),
+			expected: Model{
+				trunks: []config.Trunk{{Repositories: "release-*", Branches: []string{"develop", "main"}}},
+			},
+		},
+		{
+			name:   "Prune Remote",
+			option: PruneRemote("origin"),
+			expected: Model{
+				pruneRemote: "origin",
+			},
+		},
+		{
+			name:   "Deepen",
+			option: Deepen(100),
+			expected: Model{
+				deepen: 100,
+			},
+		},
+		{
+			name:   "Shallow Since",
+			option: ShallowSince("2022-01-01"),
+			expected: Model{
+				shallowSince: "2022-01-01",
+			},
+		},
+		{
+			name:   "Delete Gone Branches",
+			option: DeleteGoneBranches(true),
+			expected: Model{
+				deleteGoneBranches: true,
+			},
+		},
+		{
+			name:   "Pre-Delete Hook",
+			option: PreDeleteHook("./check.sh"),
+			expected: Model{
+				preDeleteHook: "./check.sh",
+			},
+		},
+		{
+			name:   "Post-Delete Hook",
+			option: PostDeleteHook("./notify.sh"),
+			expected: Model{
+				postDeleteHook: "./notify.sh",
+			},
+		},
+		{
+			name:   "Providers",
+			option: Providers(map[string]provider.Provider{"github.com": nil}),
+			expected: Model{
+				providers: map[string]provider.Provider{"github.com": nil},
+			},
+		},
+		{
+			name:   "Logger",
+			option: Logger(slog.Default()),
+			expected: Model{
+				logger: slog.Default(),
+			},
+		},
+		{
+			name:   "Styles",
+			option: Styles(themes["monochrome"]),
+			expected: Model{
+				theme: themes["monochrome"],
+			},
+		},
+		{
+			name:   "Keys",
+			option: Keys(DefaultKeyMap()),
+			expected: Model{
+				keys: DefaultKeyMap(),
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The plain mode prints the progress of the repositories as lines of text as the events happen instead of rendering
+// the TUI. It is used when the output is not a terminal (e.g. CI or pipes), where the alt-screen garbles the logs.
+
+func (m *Model) isPlain() bool {
+	return m.output != nil
+}
+
+func (m *Model) printf(format string, a ...interface{}) {
+	_, _ = fmt.Fprintf(m.output, format+"\n", a...)
+}
+
+func (m *Model) printStarted(position int) {
+	m.printf("%s: started", m.repositories[position].Name)
+}
+
+func (m *Model) printCompleted(msg completedMsg) {
+	name := m.repositories[msg.position].Name
+	if len(msg.skipReason) > 0 {
+		m.printf("%s: skipped (%s)", name, msg.skipReason)
+		return
+	}
+	// nothing is deleted on a dry run, so the branches are printed as the ones that would be deleted
+	verb := "deleted"
+	if m.dryRun {
+		verb = "would delete"
+	}
+	for _, branch := range msg.branches {
+		m.printf("%s: %s %s", name, verb, branch)
+	}
+	for _, skipped := range msg.skipped {
+		m.printf("%s: skipped %s (%s)", name, skipped.name, skipped.reason)
+	}
+	for _, warning := range msg.warnings {
+		m.printf("%s: warning %s", name, warning)
+	}
+	for _, err := range msg.errs {
+		m.printf("%s: error %s", name, err)
+	}
+	if msg.errs != nil {
+		m.printf("%s: failed", name)
+	} else {
+		m.printf("%s: completed", name)
+	}
+}
+
+func (m *Model) printSummary() {
+	m.printf("\n%s", strings.TrimSuffix(getSummary(m), "\n"))
+}
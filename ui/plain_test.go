@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"lopper/git"
+	"testing"
+)
+
+func TestPrintCompleted(t *testing.T) {
+	tests := []struct {
+		name     string
+		dryRun   bool
+		result   processResult
+		expected string
+	}{
+		{
+			name: "Deleted",
+			result: processResult{
+				branches: []deletedBranch{{name: "feature"}, {name: "fix", pullRequest: 12}},
+			},
+			expected: "project: deleted feature\n" +
+				"project: deleted fix (#12)\n" +
+				"project: completed\n",
+		},
+		{
+			name:   "Dry Run",
+			dryRun: true,
+			result: processResult{
+				branches: []deletedBranch{{name: "feature"}, {name: "fix", prunedRemote: "fork"}},
+			},
+			expected: "project: would delete feature\n" +
+				"project: would delete fix (also on fork)\n" +
+				"project: completed\n",
+		},
+		{
+			name:     "Skipped Repository",
+			result:   processResult{skipReason: "the main branch does not exist locally"},
+			expected: "project: skipped (the main branch does not exist locally)\n",
+		},
+		{
+			name: "Skipped Branches And Warnings",
+			result: processResult{
+				branches: []deletedBranch{{name: "feature", reducedConfidence: true}},
+				skipped:  []skippedBranch{{name: "docs", reason: "checked out"}},
+				warnings: []string{"squash merges into main could not be detected without the full history"},
+			},
+			expected: "project: deleted feature (reduced confidence)\n" +
+				"project: skipped docs (checked out)\n" +
+				"project: warning squash merges into main could not be detected without the full history\n" +
+				"project: completed\n",
+		},
+		{
+			name: "Failed",
+			result: processResult{
+				branches: []deletedBranch{{name: "feature"}},
+				errs:     []error{errors.New("failed to delete branch fix")},
+			},
+			expected: "project: deleted feature\n" +
+				"project: error failed to delete branch fix\n" +
+				"project: failed\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+			m := NewModel(DryRun(test.dryRun), Output(&output))
+			m.repositories = []git.Repository{{Name: "project"}}
+			m.printCompleted(completedMsg{position: 0, processResult: test.result})
+			assert.Equal(t, test.expected, output.String())
+		})
+	}
+}
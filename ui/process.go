@@ -0,0 +1,577 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"lopper/config"
+	"lopper/git"
+	"lopper/hooks"
+	"lopper/provider"
+	"lopper/utils"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+func (m *Model) process(ctx context.Context, repo git.Repository) processResult {
+	fullPath := filepath.Join(repo.Path, repo.Name)
+	trunks, skipReason, err := m.updateTrunks(ctx, repo, fullPath)
+	if err != nil {
+		return newErrorResult(updateErrorType, err)
+	}
+	if len(skipReason) > 0 {
+		return processResult{skipReason: skipReason}
+	}
+	// the history of a shallow clone may not reach the merge bases, so deepen it when asked to
+	clone := getClone(ctx, fullPath)
+	if clone == shallowClone && (m.deepen > 0 || len(m.shallowSince) > 0) {
+		if err := git.Deepen(ctx, fullPath, m.deepen, m.shallowSince); err != nil {
+			return newErrorResult(updateErrorType, err)
+		}
+		clone = getClone(ctx, fullPath)
+	}
+	candidates, warnings, err := getMergedCandidates(ctx, fullPath, trunks, clone)
+	if err != nil {
+		return newErrorResult(detectionErrorType, err)
+	}
+	localTrunks := make([]string, 0, len(trunks))
+	revisions := make([]string, 0, len(trunks))
+	for _, t := range trunks {
+		localTrunks = append(localTrunks, t.name)
+		revisions = append(revisions, t.revision)
+	}
+	// without the full history, fall back to the strategies that do not need it
+	var skipped []skippedBranch
+	if clone == shallowClone {
+		goneBranches, err := m.getGoneBranches(ctx, fullPath, localTrunks, candidates)
+		if err != nil {
+			return newErrorResult(detectionErrorType, err)
+		}
+		// the upstream branch may have been deleted without merging the branch, so it is only deleted when asked to
+		if m.deleteGoneBranches {
+			candidates = append(candidates, goneBranches...)
+		} else {
+			for _, branch := range goneBranches {
+				skipped = append(skipped, skippedBranch{name: branch.name, reason: branch.reason + ", but it may not have been merged"})
+			}
+		}
+	}
+	// the hosting provider is asked about the pull requests of the branches, if the repository is hosted by one
+	hostingProvider, hostedRepo := m.getProvider(ctx, fullPath)
+
+	// branches checked out in a linked worktree cannot be deleted until the worktree is removed
+	worktrees, err := getCheckedOutWorktrees(ctx, fullPath)
+	if err != nil {
+		return newErrorResult(worktreeErrorType, err)
+	}
+
+	// the trunks are not checked out when they are compared against their remote-tracking branches (e.g. in
+	// submodules), so a merged branch may still be checked out
+	var currentBranch string
+	if !repo.Bare {
+		if currentBranch, err = git.GetCurrentBranch(ctx, fullPath); err != nil {
+			return newErrorResult(detectionErrorType, err)
+		}
+	}
+
+	// the commits have to be retrieved before the branches are deleted
+	commits, err := git.GetBranchCommits(ctx, fullPath)
+	if err != nil {
+		return newErrorResult(detectionErrorType, err)
+	}
+	for i := range candidates {
+		candidates[i].commit = commits[candidates[i].name]
+	}
+	result := processResult{trunks: revisions, clone: clone, skipped: skipped, warnings: warnings}
+	// the remote-tracking branches of the prune remote are not updated otherwise, so a branch deleted from the remote
+	// since the last fetch would be deleted again
+	pruneRemote := m.pruneRemote
+	if len(pruneRemote) > 0 {
+		if err := git.FetchPrunedRemote(ctx, fullPath, pruneRemote); err != nil {
+			result.errs = append(result.errs, processError{errorType: updateErrorType, err: err})
+			pruneRemote = ""
+		}
+	}
+	// the pull requests are retrieved once, a failing provider does not keep the branches detected locally from being
+	// deleted
+	openPullRequests := make(map[string]provider.PullRequest)
+	var openErr error
+	if hostingProvider != nil {
+		// pull requests merged with edits are not detected locally, but the hosting provider knows they have been merged
+		providerBranches, err := m.getProviderMergedBranches(ctx, fullPath, hostingProvider, hostedRepo, localTrunks, candidates, commits)
+		if err != nil {
+			result.errs = append(result.errs, processError{errorType: providerErrorType, err: err})
+		}
+		candidates = append(candidates, providerBranches...)
+		pullRequests, err := hostingProvider.GetOpenPullRequests(ctx, hostedRepo)
+		if err != nil {
+			openErr = err
+			result.errs = append(result.errs, processError{errorType: providerErrorType, err: err})
+		}
+		for _, pr := range pullRequests {
+			if _, ok := openPullRequests[pr.Head]; !ok {
+				openPullRequests[pr.Head] = pr
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		branch := candidate.name
+		// skip protected branches
+		if utils.Contains(m.protectedBranches, branch) {
+			continue
+		}
+		// a follow-up pull request can still be open from a branch that has been merged before
+		if openErr != nil {
+			result.skipped = append(result.skipped, skippedBranch{name: branch, reason: "the open pull requests could not be retrieved"})
+			continue
+		}
+		if pr, ok := openPullRequests[branch]; ok {
+			result.skipped = append(result.skipped, skippedBranch{name: branch, reason: fmt.Sprintf("pull request #%d is open", pr.Number)})
+			continue
+		}
+		if branch == currentBranch {
+			result.skipped = append(result.skipped, skippedBranch{name: branch, reason: "checked out"})
+			continue
+		}
+		worktree, checkedOut := worktrees[branch]
+		if checkedOut {
+			if reason := m.checkWorktree(ctx, worktree); len(reason) > 0 {
+				result.skipped = append(result.skipped, skippedBranch{name: branch, reason: reason})
+				continue
+			}
+		}
+		// the pre-delete hook can refuse the deletion of the branch, even on a dry run. It is run once the branch is
+		// known to be deleted otherwise, so it is not run for the branches that are skipped anyway
+		if len(m.preDeleteHook) > 0 {
+			if err = hooks.RunBranch(ctx, m.preDeleteHook, m.newHookBranch(fullPath, candidate)); err != nil {
+				result.skipped = append(result.skipped, skippedBranch{name: branch, reason: err.Error()})
+				continue
+			}
+		}
+		if checkedOut && !m.dryRun {
+			if err = git.RemoveWorktree(ctx, fullPath, worktree.Path); err != nil {
+				result.errs = append(result.errs, processError{errorType: worktreeErrorType, err: err})
+				continue
+			}
+		}
+		// the matching branch of the fork is pruned along with the local branch
+		if len(pruneRemote) > 0 && git.RemoteBranchExists(ctx, fullPath, pruneRemote, branch) {
+			candidate.prunedRemote = pruneRemote
+		}
+		// if a dry run, just add the branch to the list of deleted branches
+		if m.dryRun {
+			result.branches = append(result.branches, candidate)
+		} else {
+			// try to delete the branch
+			if err = deleteBranch(ctx, repo, fullPath, branch); err != nil {
+				result.errs = append(result.errs, processError{errorType: deleteErrorType, err: err})
+				continue
+			}
+			if len(candidate.prunedRemote) > 0 {
+				if err := git.DeleteRemoteBranch(ctx, fullPath, candidate.prunedRemote, branch); err != nil {
+					result.errs = append(result.errs, processError{errorType: deleteErrorType, err: err})
+					candidate.prunedRemote = ""
+				}
+			}
+			// if successful, add the branch to the list of deleted branches
+			result.branches = append(result.branches, candidate)
+			if len(m.postDeleteHook) > 0 {
+				if err = hooks.RunBranch(ctx, m.postDeleteHook, m.newHookBranch(fullPath, candidate)); err != nil {
+					result.errs = append(result.errs, processError{errorType: hookErrorType, err: fmt.Errorf("post-delete hook of branch %s failed: %w", branch, err)})
+				}
+			}
+		}
+	}
+	return result
+}
+
+// newHookBranch returns the hooks.Branch the hooks are run with for the given candidate.
+func (m *Model) newHookBranch(path string, candidate deletedBranch) hooks.Branch {
+	return hooks.Branch{
+		Repository: path,
+		Branch:     candidate.name,
+		SHA:        candidate.commit.SHA,
+		Strategy:   string(candidate.strategy),
+		Reason:     candidate.reason,
+		DryRun:     m.dryRun,
+	}
+}
+
+func newErrorResult(errorType errorType, err error) processResult {
+	return processResult{errs: []error{processError{errorType: errorType, err: err}}}
+}
+
+// trunk is a branch the branches of a repository are merged into.
+type trunk struct {
+	// name is the name of the local branch (e.g. main).
+	name string
+	// revision is the revision the branches are compared against, which is the local branch unless the trunk of
+	// another remote is authoritative (e.g. upstream/main).
+	revision string
+}
+
+// updateTrunks updates the trunk branches of the given repository and returns them. A skip reason is returned when the
+// repository does not have any of its trunk branches.
+func (m *Model) updateTrunks(ctx context.Context, repo git.Repository, path string) ([]trunk, string, error) {
+	names, all := config.TrunkNames(m.trunks, repo)
+	// checking out the trunks of a submodule would move its HEAD away from the commit recorded in the parent
+	// repository, so submodules are compared against the trunks of the remote without touching the working tree
+	if repo.Depth > 0 {
+		remote := "origin"
+		if len(m.trunkRemote) > 0 {
+			remote = m.trunkRemote
+		}
+		return fetchRemoteTrunks(ctx, path, remote, names, all)
+	}
+	// a mirror clone is a local cache of the remote, whose refs are all overwritten by fetching it, so it is fetched
+	// before the trunks are resolved. The branches deleted from the remote are pruned, and the merged branches that still
+	// exist on the remote are deleted until the next fetch restores them
+	mirror := repo.Bare && git.IsMirror(ctx, path, "origin")
+	if mirror {
+		if err := git.FetchPrunedRemote(ctx, path, "origin"); err != nil {
+			return nil, "", err
+		}
+	}
+	var existing []string
+	for _, name := range names {
+		if git.BranchExists(ctx, path, name) {
+			existing = append(existing, name)
+			if !all {
+				break
+			}
+		}
+	}
+	var trunks []trunk
+	if repo.Bare {
+		if len(existing) == 0 {
+			return nil, "the main branch does not exist", nil
+		}
+		// bare repositories do not have a working tree, so the refs are updated without checking out the trunks
+		if !mirror {
+			remotes, err := git.GetRemotes(ctx, path)
+			if err != nil {
+				return nil, "", err
+			}
+			if utils.Contains(remotes, "origin") {
+				if err = git.Fetch(ctx, path, "origin", existing); err != nil {
+					return nil, "", err
+				}
+			}
+		}
+		for _, name := range existing {
+			trunks = append(trunks, trunk{name: name, revision: name})
+		}
+		return trunks, "", nil
+	}
+	// the checkouts of the repo tool usually have a detached HEAD without a local main branch, so they are compared
+	// against the remote-tracking main branch they have been synced from instead
+	if len(existing) == 0 && len(repo.TrunkRemote) > 0 {
+		remote := repo.TrunkRemote
+		if len(m.trunkRemote) > 0 {
+			remote = m.trunkRemote
+		}
+		return fetchRemoteTrunks(ctx, path, remote, names, all)
+	}
+	if len(existing) == 0 {
+		return nil, "the main branch does not exist locally", nil
+	}
+	// the trunks are checked out to be pulled, so the branch the user is on is checked out again afterwards
+	restore, err := getRestorePoint(ctx, path)
+	if err != nil {
+		return nil, "", err
+	}
+	// a trunk that exists but cannot be checked out (e.g. because of uncommitted changes) is a failure, not a reason to
+	// skip the repository, since the branches merged into it would not be detected
+	for _, name := range existing {
+		if err := git.CheckoutBranch(ctx, path, name); err != nil {
+			_ = restore(ctx)
+			return nil, "", err
+		}
+		if len(m.trunkRemote) == 0 {
+			// ensure everything is up to date so we know for sure which branches are dead (merged)
+			if err := git.Pull(ctx, path); err != nil {
+				_ = restore(ctx)
+				return nil, "", err
+			}
+		}
+		trunks = append(trunks, trunk{name: name, revision: name})
+	}
+	if err := restore(ctx); err != nil {
+		return nil, "", err
+	}
+	if len(m.trunkRemote) == 0 {
+		return trunks, "", nil
+	}
+	// the local trunks may track a fork, so compare against the trunks of the authoritative remote
+	if err := git.FetchRemote(ctx, path, m.trunkRemote); err != nil {
+		return nil, "", err
+	}
+	remoteTrunks := make([]trunk, 0, len(trunks))
+	for _, t := range trunks {
+		// configured trunks must exist on the remote under the same name, while the main branch may be named differently
+		if all {
+			if git.RemoteBranchExists(ctx, path, m.trunkRemote, t.name) {
+				remoteTrunks = append(remoteTrunks, trunk{name: t.name, revision: m.trunkRemote + "/" + t.name})
+			}
+		} else if revision := git.GetRemoteTrunk(ctx, path, m.trunkRemote, t.name); len(revision) > 0 {
+			remoteTrunks = append(remoteTrunks, trunk{name: t.name, revision: revision})
+		}
+	}
+	if len(remoteTrunks) == 0 {
+		return nil, fmt.Sprintf("the main branch does not exist on remote %s", m.trunkRemote), nil
+	}
+	return remoteTrunks, "", nil
+}
+
+// getRestorePoint returns a function that checks out the branch, or the commit of a detached HEAD, that is checked out
+// in the given repository now.
+func getRestorePoint(ctx context.Context, path string) (func(ctx context.Context) error, error) {
+	branch, err := git.GetCurrentBranch(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(branch) > 0 {
+		return func(ctx context.Context) error {
+			return git.CheckoutBranch(ctx, path, branch)
+		}, nil
+	}
+	commit, err := git.GetHead(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		return git.CheckoutCommit(ctx, path, commit)
+	}, nil
+}
+
+// fetchRemoteTrunks fetches the given remote and returns the remote-tracking branches of the given trunks, without
+// checking them out. A skip reason is returned when the remote does not have any of the trunk branches.
+func fetchRemoteTrunks(ctx context.Context, path string, remote string, names []string, all bool) ([]trunk, string, error) {
+	if err := git.FetchRemote(ctx, path, remote); err != nil {
+		return nil, "", err
+	}
+	var trunks []trunk
+	for _, name := range names {
+		if git.RemoteBranchExists(ctx, path, remote, name) {
+			trunks = append(trunks, trunk{name: name, revision: remote + "/" + name})
+			if !all {
+				break
+			}
+		}
+	}
+	if len(trunks) == 0 {
+		return nil, fmt.Sprintf("the main branch does not exist on remote %s", remote), nil
+	}
+	return trunks, "", nil
+}
+
+// getMergedCandidates returns the branches that have been merged or squash merged into any of the given trunks. Each
+// branch is attributed to the first trunk it has been merged into. In shallow clones, the branches that cannot be
+// compared for squash merges are left out, and returned as warnings instead of failing the repository.
+func getMergedCandidates(ctx context.Context, path string, trunks []trunk, clone clone) ([]deletedBranch, []string, error) {
+	var warnings []string
+	var candidates []deletedBranch
+	// the trunks are merged into each other (e.g. main into develop), but they are not dead branches
+	detected := make([]string, 0, len(trunks))
+	for _, t := range trunks {
+		detected = append(detected, t.name)
+	}
+	for _, t := range trunks {
+		// get all branches that have been merged into the trunk
+		mergedBranches, err := git.GetMergedBranches(ctx, path, t.revision)
+		if err != nil {
+			return nil, nil, err
+		}
+		var merged []string
+		for _, branch := range mergedBranches {
+			if !utils.Contains(detected, branch) {
+				merged = append(merged, branch)
+			}
+		}
+		detected = append(detected, merged...)
+		squashedBranches, err := git.GetMergedSquashedBranches(ctx, path, t.revision, detected)
+		if err != nil {
+			if clone != shallowClone {
+				return nil, nil, err
+			}
+			// the merge bases of some branches are missing from the history, but the other branches have been compared
+			warnings = append(warnings, fmt.Sprintf("squash merges into %s could not be detected without the full history: %s", t.revision, err))
+		}
+		detected = append(detected, squashedBranches...)
+		for _, branch := range merged {
+			candidates = append(candidates, deletedBranch{
+				name:     branch,
+				strategy: mergedStrategy,
+				reason:   fmt.Sprintf("the tip is reachable from %s", t.revision),
+				trunk:    t.revision,
+			})
+		}
+		for _, branch := range squashedBranches {
+			candidates = append(candidates, deletedBranch{
+				name:     branch,
+				strategy: squashedStrategy,
+				reason:   fmt.Sprintf("the changes have been squash merged into %s", t.revision),
+				trunk:    t.revision,
+				// the merge base may be wrong when the history does not reach it
+				reducedConfidence: clone == shallowClone,
+			})
+		}
+	}
+	return candidates, warnings, nil
+}
+
+// deleteBranch deletes the given branch. The ref is deleted directly for bare repositories.
+func deleteBranch(ctx context.Context, repo git.Repository, path string, branch string) error {
+	if repo.Bare {
+		return git.DeleteRef(ctx, path, branch)
+	}
+	return git.DeleteBranch(ctx, path, branch)
+}
+
+// getProvider returns the provider.Provider hosting the given repository and the repository on the provider. A nil
+// provider.Provider is returned when the origin of the repository is not hosted by a configured provider.
+func (m *Model) getProvider(ctx context.Context, path string) (provider.Provider, provider.Repository) {
+	if len(m.providers) == 0 {
+		return nil, provider.Repository{}
+	}
+	remoteURL, err := git.GetRemoteURL(ctx, path, "origin")
+	if err != nil {
+		return nil, provider.Repository{}
+	}
+	repo, err := provider.ParseRemoteURL(remoteURL)
+	if err != nil {
+		return nil, provider.Repository{}
+	}
+	return m.providers[repo.Host], repo
+}
+
+// getClone returns the kind of clone of the given repository.
+func getClone(ctx context.Context, path string) clone {
+	if git.IsShallowRepository(ctx, path) {
+		return shallowClone
+	}
+	if git.IsPartialClone(ctx, path) {
+		return partialClone
+	}
+	return fullClone
+}
+
+// getGoneBranches returns the branches that have not been detected as merged, but whose upstream branch has been
+// deleted from the remote, which usually happens once a pull request has been merged. Since the upstream branch may
+// have been deleted without merging it, the branches are detected with reduced confidence.
+func (m *Model) getGoneBranches(ctx context.Context, path string, trunks []string, candidates []deletedBranch) ([]deletedBranch, error) {
+	gone, err := git.GetGoneBranches(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	detected := make(map[string]bool)
+	for _, candidate := range candidates {
+		detected[candidate.name] = true
+	}
+	var branches []deletedBranch
+	for branch, upstream := range gone {
+		if utils.Contains(trunks, branch) || detected[branch] || utils.Contains(m.protectedBranches, branch) {
+			continue
+		}
+		branches = append(branches, deletedBranch{
+			name:              branch,
+			strategy:          upstreamGoneStrategy,
+			reason:            fmt.Sprintf("the upstream branch %s has been deleted", upstream),
+			reducedConfidence: true,
+		})
+	}
+	sort.Slice(branches, func(i, j int) bool {
+		return branches[i].name < branches[j].name
+	})
+	return branches, nil
+}
+
+// getProviderMergedBranches returns the branches that have not been detected as merged locally, but whose pull request
+// has been merged according to the hosting provider. A branch is merged when its tip is the head of the merged pull
+// request, or an ancestor of it.
+func (m *Model) getProviderMergedBranches(ctx context.Context, path string, hostingProvider provider.Provider, repo provider.Repository, trunks []string, candidates []deletedBranch, commits map[string]git.Commit) ([]deletedBranch, error) {
+	detected := make(map[string]bool)
+	for _, candidate := range candidates {
+		detected[candidate.name] = true
+	}
+	unmerged := make(map[string]git.Commit)
+	var since time.Time
+	for branch, commit := range commits {
+		if utils.Contains(trunks, branch) || detected[branch] || utils.Contains(m.protectedBranches, branch) {
+			continue
+		}
+		unmerged[branch] = commit
+		if since.IsZero() || commit.Date.Before(since) {
+			since = commit.Date
+		}
+	}
+	if len(unmerged) == 0 {
+		return nil, nil
+	}
+	// a pull request is merged after the commits of its branch, so older pull requests cannot have merged the branches.
+	// A day is added for the clocks of the committers that are ahead of the clock of the provider.
+	pullRequests, err := hostingProvider.GetMergedPullRequests(ctx, repo, since.Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	merged := make(map[string][]provider.PullRequest)
+	for _, pr := range pullRequests {
+		merged[pr.Head] = append(merged[pr.Head], pr)
+	}
+	var branches []deletedBranch
+	for branch, commit := range unmerged {
+		for _, pr := range merged[branch] {
+			if commit.SHA == pr.HeadSHA || git.IsAncestor(ctx, path, commit.SHA, pr.HeadSHA) {
+				branches = append(branches, deletedBranch{
+					name:        branch,
+					strategy:    providerStrategy,
+					reason:      fmt.Sprintf("pull request #%d has been merged", pr.Number),
+					commit:      commit,
+					pullRequest: pr.Number,
+				})
+				break
+			}
+		}
+	}
+	// the branches are ordered like the branches detected locally
+	sort.Slice(branches, func(i, j int) bool {
+		return branches[i].name < branches[j].name
+	})
+	return branches, nil
+}
+
+// getCheckedOutWorktrees returns the linked worktrees of the given repository keyed by the branch checked out in them.
+func getCheckedOutWorktrees(ctx context.Context, path string) (map[string]git.Worktree, error) {
+	worktrees, err := git.GetWorktrees(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	checkedOut := make(map[string]git.Worktree)
+	for i, worktree := range worktrees {
+		// the first worktree is the main worktree, which has the main branch checked out
+		if i == 0 || len(worktree.Branch) == 0 {
+			continue
+		}
+		checkedOut[worktree.Branch] = worktree
+	}
+	return checkedOut, nil
+}
+
+// checkWorktree returns the reason the branch checked out in the given worktree cannot be deleted. An empty reason
+// means the worktree can be removed.
+func (m *Model) checkWorktree(ctx context.Context, worktree git.Worktree) string {
+	if !m.pruneWorktrees {
+		return fmt.Sprintf("checked out in worktree %s", worktree.Path)
+	}
+	if worktree.Locked {
+		return fmt.Sprintf("worktree %s is locked", worktree.Path)
+	}
+	clean, err := git.IsClean(ctx, worktree.Path)
+	if err != nil {
+		return err.Error()
+	}
+	if !clean {
+		return fmt.Sprintf("worktree %s has uncommitted changes", worktree.Path)
+	}
+	return ""
+}
@@ -0,0 +1,343 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lopper/config"
+	"lopper/git"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+// runGit runs the given Git command in the given directory.
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=lopper", "GIT_AUTHOR_EMAIL=lopper@example.com",
+		"GIT_COMMITTER_NAME=lopper", "GIT_COMMITTER_EMAIL=lopper@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestProcessDetachedHead(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	upstream := filepath.Join(dir, "upstream")
+	require.NoError(t, os.Mkdir(upstream, 0755))
+	runGit(t, upstream, "init", "--quiet", "--initial-branch=main")
+	runGit(t, upstream, "commit", "--quiet", "--allow-empty", "--message=initial")
+	runGit(t, upstream, "checkout", "--quiet", "-b", "feature")
+	runGit(t, upstream, "commit", "--quiet", "--allow-empty", "--message=feature")
+	runGit(t, upstream, "checkout", "--quiet", "main")
+	runGit(t, upstream, "merge", "--quiet", "--no-ff", "--message=merge", "feature")
+
+	// like a checkout of the repo tool, the HEAD is detached and there is no local main branch
+	runGit(t, dir, "clone", "--quiet", upstream, "project")
+	project := filepath.Join(dir, "project")
+	runGit(t, project, "checkout", "--quiet", "--detach", "origin/main")
+	runGit(t, project, "branch", "--quiet", "-D", "main")
+	runGit(t, project, "branch", "--quiet", "feature", "origin/feature")
+
+	m := NewModel(DryRun(true))
+	result := m.process(context.Background(), git.Repository{
+		Path:        dir,
+		Name:        "project",
+		Trunk:       "main",
+		TrunkRemote: "origin",
+	})
+	assert.Empty(t, result.skipReason)
+	assert.Empty(t, result.errs)
+	assert.Equal(t, []string{"origin/main"}, result.trunks)
+	require.Len(t, result.branches, 1)
+	assert.Equal(t, "feature", result.branches[0].name)
+}
+
+func TestProcessCheckoutFailure(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	project := filepath.Join(dir, "project")
+	require.NoError(t, os.Mkdir(project, 0755))
+	runGit(t, project, "init", "--quiet", "--initial-branch=main")
+	require.NoError(t, os.WriteFile(filepath.Join(project, "file"), []byte("main"), 0644))
+	runGit(t, project, "add", "file")
+	runGit(t, project, "commit", "--quiet", "--message=initial")
+	runGit(t, project, "checkout", "--quiet", "-b", "feature")
+	require.NoError(t, os.WriteFile(filepath.Join(project, "file"), []byte("feature"), 0644))
+	runGit(t, project, "commit", "--quiet", "--all", "--message=feature")
+	// the uncommitted change would be overwritten by checking out main
+	require.NoError(t, os.WriteFile(filepath.Join(project, "file"), []byte("uncommitted"), 0644))
+
+	m := NewModel(DryRun(true))
+	result := m.process(context.Background(), git.Repository{Path: dir, Name: "project"})
+	assert.Empty(t, result.skipReason)
+	require.Len(t, result.errs, 1)
+	var processErr processError
+	require.ErrorAs(t, result.errs[0], &processErr)
+	assert.Equal(t, updateErrorType, processErr.errorType)
+	// the standard error of git, which lists the file, is included
+	assert.ErrorContains(t, processErr, "failed to checkout branch main: ")
+	assert.ErrorContains(t, processErr, "file")
+}
+
+// commitFile commits the given content to the given file in the given directory.
+func commitFile(t *testing.T, dir string, file string, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0644))
+	runGit(t, dir, "add", file)
+	runGit(t, dir, "commit", "--quiet", "--message="+file)
+}
+
+func TestProcessTrunks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	upstream := filepath.Join(dir, "upstream")
+	require.NoError(t, os.Mkdir(upstream, 0755))
+	runGit(t, upstream, "init", "--quiet", "--initial-branch=main")
+	commitFile(t, upstream, "initial", "initial")
+	runGit(t, upstream, "branch", "develop")
+	runGit(t, dir, "clone", "--quiet", upstream, "project")
+	project := filepath.Join(dir, "project")
+	runGit(t, project, "branch", "--quiet", "--track", "develop", "origin/develop")
+
+	// the feature is merged into develop and the hotfix into main
+	runGit(t, project, "checkout", "--quiet", "-b", "feature", "develop")
+	commitFile(t, project, "feature", "feature")
+	runGit(t, project, "checkout", "--quiet", "-b", "hotfix", "main")
+	commitFile(t, project, "hotfix", "hotfix")
+	runGit(t, upstream, "checkout", "--quiet", "--detach")
+	runGit(t, upstream, "fetch", "--quiet", project, "feature:develop", "hotfix:main")
+	// the user is working on a branch that has not been merged
+	runGit(t, project, "checkout", "--quiet", "-b", "wip", "main")
+	commitFile(t, project, "wip", "wip")
+
+	m := NewModel(DryRun(true), Trunks([]config.Trunk{{Repositories: "project", Branches: []string{"main", "develop"}}}))
+	result := m.process(context.Background(), git.Repository{Path: dir, Name: "project"})
+	assert.Empty(t, result.skipReason)
+	assert.Empty(t, result.errs)
+	assert.Equal(t, []string{"main", "develop"}, result.trunks)
+	trunks := make(map[string]string)
+	for _, branch := range result.branches {
+		trunks[branch.name] = branch.trunk
+	}
+	assert.Equal(t, map[string]string{"hotfix": "main", "feature": "develop"}, trunks)
+	// the branch the user was on is checked out again
+	current, err := git.GetCurrentBranch(context.Background(), project)
+	require.NoError(t, err)
+	assert.Equal(t, "wip", current)
+}
+
+func TestProcessTrunkCheckoutFailure(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	project := filepath.Join(dir, "project")
+	require.NoError(t, os.Mkdir(project, 0755))
+	runGit(t, project, "init", "--quiet", "--initial-branch=main")
+	commitFile(t, project, "file", "main")
+	runGit(t, project, "checkout", "--quiet", "-b", "develop")
+	commitFile(t, project, "file", "develop")
+	runGit(t, project, "checkout", "--quiet", "main")
+	// the uncommitted change can be carried over to main, but would be overwritten by checking out develop
+	require.NoError(t, os.WriteFile(filepath.Join(project, "file"), []byte("uncommitted"), 0644))
+
+	// with a trunk remote, the trunks are not pulled, so the repository does not need an upstream
+	m := NewModel(DryRun(true), TrunkRemote("origin"), Trunks([]config.Trunk{{Repositories: "project", Branches: []string{"main", "develop"}}}))
+	result := m.process(context.Background(), git.Repository{Path: dir, Name: "project"})
+	require.Len(t, result.errs, 1)
+	assert.ErrorContains(t, result.errs[0], "failed to checkout branch develop")
+	current, err := git.GetCurrentBranch(context.Background(), project)
+	require.NoError(t, err)
+	assert.Equal(t, "main", current)
+}
+
+// newMergedUpstream creates a repository in the given directory whose feature branch has been merged into main.
+func newMergedUpstream(t *testing.T, dir string) string {
+	t.Helper()
+	upstream := filepath.Join(dir, "upstream")
+	require.NoError(t, os.Mkdir(upstream, 0755))
+	runGit(t, upstream, "init", "--quiet", "--initial-branch=main")
+	commitFile(t, upstream, "initial", "initial")
+	runGit(t, upstream, "checkout", "--quiet", "-b", "feature")
+	commitFile(t, upstream, "feature", "feature")
+	runGit(t, upstream, "checkout", "--quiet", "main")
+	runGit(t, upstream, "merge", "--quiet", "--no-ff", "--message=merge", "feature")
+	return upstream
+}
+
+// getBranches returns the local branches of the given repository.
+func getBranches(t *testing.T, path string) []string {
+	t.Helper()
+	commits, err := git.GetBranchCommits(context.Background(), path)
+	require.NoError(t, err)
+	var branches []string
+	for branch := range commits {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+	return branches
+}
+
+func TestProcessBare(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	upstream := newMergedUpstream(t, dir)
+	runGit(t, dir, "clone", "--quiet", "--bare", upstream, "project.git")
+
+	m := NewModel()
+	result := m.process(context.Background(), git.Repository{Path: dir, Name: "project.git", Bare: true})
+	assert.Empty(t, result.skipReason)
+	assert.Empty(t, result.errs)
+	assert.Equal(t, []string{"main"}, result.trunks)
+	require.Len(t, result.branches, 1)
+	assert.Equal(t, "feature", result.branches[0].name)
+	assert.Equal(t, []string{"main"}, getBranches(t, filepath.Join(dir, "project.git")))
+}
+
+func TestProcessMirror(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	upstream := newMergedUpstream(t, dir)
+	runGit(t, upstream, "branch", "stale")
+	runGit(t, dir, "clone", "--quiet", "--mirror", upstream, "project.git")
+	// the stale branch is deleted from the remote after the mirror has been cloned
+	runGit(t, upstream, "branch", "--quiet", "-D", "stale")
+
+	m := NewModel()
+	result := m.process(context.Background(), git.Repository{Path: dir, Name: "project.git", Bare: true})
+	assert.Empty(t, result.skipReason)
+	assert.Empty(t, result.errs)
+	assert.Equal(t, []string{"main"}, result.trunks)
+	// the stale branch is pruned by fetching the mirror, so only the merged branch is reported
+	require.Len(t, result.branches, 1)
+	assert.Equal(t, "feature", result.branches[0].name)
+	assert.Equal(t, []string{"main"}, getBranches(t, filepath.Join(dir, "project.git")))
+}
+
+func TestProcessShallowClone(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tests := []struct {
+		name     string
+		options  []Option
+		clone    clone
+		warnings int
+	}{
+		{name: "Shallow", clone: shallowClone, warnings: 1},
+		{name: "Deepened", options: []Option{Deepen(10)}, clone: fullClone},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			upstream := filepath.Join(dir, "upstream")
+			require.NoError(t, os.Mkdir(upstream, 0755))
+			runGit(t, upstream, "init", "--quiet", "--initial-branch=main")
+			commitFile(t, upstream, "a", "a")
+			runGit(t, upstream, "branch", "old")
+			commitFile(t, upstream, "b", "b")
+			runGit(t, upstream, "checkout", "--quiet", "-b", "squashed")
+			commitFile(t, upstream, "c", "c")
+			runGit(t, upstream, "checkout", "--quiet", "old")
+			commitFile(t, upstream, "e", "e")
+			runGit(t, upstream, "checkout", "--quiet", "main")
+			runGit(t, upstream, "merge", "--quiet", "--squash", "squashed")
+			runGit(t, upstream, "commit", "--quiet", "--message=squashed")
+			// the history of the old branch does not reach the commit it shares with main
+			runGit(t, dir, "clone", "--quiet", "--depth=2", "--no-single-branch", "file://"+upstream, "project")
+			project := filepath.Join(dir, "project")
+			runGit(t, project, "branch", "--quiet", "squashed", "origin/squashed")
+			runGit(t, project, "branch", "--quiet", "old", "origin/old")
+
+			m := NewModel(append([]Option{DryRun(true)}, test.options...)...)
+			result := m.process(context.Background(), git.Repository{Path: dir, Name: "project"})
+			assert.Empty(t, result.errs)
+			assert.Equal(t, test.clone, result.clone)
+			assert.Len(t, result.warnings, test.warnings)
+			// the squash merged branch is detected, although the old branch could not be compared
+			require.Len(t, result.branches, 1)
+			assert.Equal(t, "squashed", result.branches[0].name)
+			assert.Equal(t, squashedStrategy, result.branches[0].strategy)
+			assert.Equal(t, test.clone == shallowClone, result.branches[0].reducedConfidence)
+		})
+	}
+}
+
+func TestProcessPreDeleteHook(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook is a shell command")
+	}
+	dir := t.TempDir()
+	upstream := filepath.Join(dir, "upstream")
+	require.NoError(t, os.Mkdir(upstream, 0755))
+	runGit(t, upstream, "init", "--quiet", "--initial-branch=main")
+	commitFile(t, upstream, "initial", "initial")
+	runGit(t, dir, "clone", "--quiet", upstream, "project")
+	project := filepath.Join(dir, "project")
+	// every branch is merged, but the current branch and the branch checked out in a worktree are skipped
+	runGit(t, project, "branch", "merged")
+	runGit(t, project, "branch", "worktree")
+	runGit(t, project, "worktree", "add", "--quiet", filepath.Join(dir, "worktree"), "worktree")
+	runGit(t, project, "checkout", "--quiet", "-b", "current")
+
+	hooked := filepath.Join(dir, "hooked")
+	m := NewModel(DryRun(true), PreDeleteHook(fmt.Sprintf("echo $LOPPER_BRANCH >> %q", hooked)))
+	result := m.process(context.Background(), git.Repository{Path: dir, Name: "project"})
+	assert.Empty(t, result.errs)
+	require.Len(t, result.branches, 1)
+	assert.Equal(t, "merged", result.branches[0].name)
+	assert.Len(t, result.skipped, 2)
+	// the hook is only run for the branch that is deleted
+	data, err := os.ReadFile(hooked)
+	require.NoError(t, err)
+	assert.Equal(t, "merged\n", string(data))
+}
+
+func TestProcessPruneRemote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	// the upstream and the fork both have the feature branch, which has been merged upstream
+	seed := newMergedUpstream(t, dir)
+	runGit(t, dir, "clone", "--quiet", "--bare", seed, "upstream.git")
+	runGit(t, dir, "clone", "--quiet", "--bare", seed, "fork.git")
+	runGit(t, dir, "clone", "--quiet", filepath.Join(dir, "fork.git"), "project")
+	project := filepath.Join(dir, "project")
+	runGit(t, project, "remote", "add", "upstream", filepath.Join(dir, "upstream.git"))
+	runGit(t, project, "branch", "--quiet", "feature", "origin/feature")
+
+	m := NewModel(TrunkRemote("upstream"), PruneRemote("origin"))
+	result := m.process(context.Background(), git.Repository{Path: dir, Name: "project"})
+	assert.Empty(t, result.errs)
+	assert.Equal(t, []string{"upstream/main"}, result.trunks)
+	require.Len(t, result.branches, 1)
+	assert.Equal(t, "feature", result.branches[0].name)
+	assert.Equal(t, "origin", result.branches[0].prunedRemote)
+	assert.Equal(t, []string{"main"}, getBranches(t, project))
+	assert.Equal(t, []string{"main"}, getBranches(t, filepath.Join(dir, "fork.git")))
+	// the branch is only deleted on the fork
+	assert.Equal(t, []string{"feature", "main"}, getBranches(t, filepath.Join(dir, "upstream.git")))
+}
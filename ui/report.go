@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"lopper/report"
+	"path/filepath"
+)
+
+var stateNames = map[state]string{
+	inprogressState: "in-progress",
+	completedState:  "completed",
+	errorState:      "error",
+	skippedState:    "skipped",
+	pausedState:     "paused",
+	cancelledState:  "cancelled",
+}
+
+// Report returns the report of the run.
+func (m *Model) Report() report.Report {
+	result := report.Report{
+		Paths:        m.paths,
+		DryRun:       m.dryRun,
+		StartTime:    m.startTime,
+		EndTime:      m.endTime,
+		Repositories: make([]report.Repository, 0, len(m.repositories)),
+	}
+	for i, r := range m.repositories {
+		repository := report.Repository{
+			Name:       r.Name,
+			Path:       filepath.Join(r.Path, r.Name),
+			State:      "pending",
+			Trunks:     m.repoTrunks[i],
+			Clone:      string(m.clones[i]),
+			SkipReason: m.skipReasons[i],
+			Warnings:   m.warnings[i],
+			DurationMS: m.durations[i].Milliseconds(),
+		}
+		if s, ok := m.states[i]; ok {
+			repository.State = stateNames[s]
+		}
+		for _, err := range m.errMessages[i] {
+			repository.Errors = append(repository.Errors, err.Error())
+		}
+		for _, branch := range m.deletedBranches[i] {
+			repository.Deleted = append(repository.Deleted, report.Branch{
+				Name:              branch.name,
+				SHA:               branch.commit.SHA,
+				Strategy:          string(branch.strategy),
+				Reason:            branch.reason,
+				Trunk:             branch.trunk,
+				PullRequest:       branch.pullRequest,
+				ReducedConfidence: branch.reducedConfidence,
+				PrunedRemote:      branch.prunedRemote,
+			})
+		}
+		for _, branch := range m.skippedBranches[i] {
+			repository.Skipped = append(repository.Skipped, report.SkippedBranch{Name: branch.name, Reason: branch.reason})
+		}
+		result.Repositories = append(result.Repositories, repository)
+	}
+	return result
+}
@@ -1,17 +1,166 @@
 package ui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"sort"
+)
 
-// Symbols
+// Theme is the colors and symbols the UI is rendered with.
+type Theme struct {
+	Colors  ThemeColors
+	Symbols ThemeSymbols
+}
 
-var symbolX = "✘"
-var symbolCheck = "✔"
-var symbolBranch = "├"
-var symbolLeaf = "└"
+// ThemeColors are the colors of each role in the UI. A role with an empty color is rendered without a color.
+type ThemeColors struct {
+	Completed string
+	Error     string
+	Spinner   string
+	Muted     string
+}
 
-// Color Styles
+// ThemeSymbols are the glyphs rendered in the UI.
+type ThemeSymbols struct {
+	Completed string
+	Error     string
+	Skipped   string
+	Paused    string
+	Branch    string
+	Leaf      string
+	Divider   string
+	// Spinner are the frames of the spinner, one frame per character.
+	Spinner string
+}
 
-var spinnerColor = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
-var completedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#008000"))
-var errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000"))
-var grayStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#808080"))
+var unicodeSymbols = ThemeSymbols{
+	Completed: "✔",
+	Error:     "✘",
+	Skipped:   "-",
+	Paused:    "⏸",
+	Branch:    "├",
+	Leaf:      "└",
+	Divider:   "│",
+	Spinner:   "⣾⣽⣻⢿⡿⣟⣯⣷",
+}
+
+var asciiSymbols = ThemeSymbols{
+	Completed: "+",
+	Error:     "x",
+	Skipped:   "-",
+	Paused:    "=",
+	Branch:    "|",
+	Leaf:      "`",
+	Divider:   "|",
+	Spinner:   `|/-\`,
+}
+
+// DefaultTheme is the name of the theme used when no theme is configured.
+const DefaultTheme = "default"
+
+// themes are the built-in themes.
+var themes = map[string]Theme{
+	DefaultTheme: {
+		Colors:  ThemeColors{Completed: "#008000", Error: "#FF0000", Spinner: "205", Muted: "#808080"},
+		Symbols: unicodeSymbols,
+	},
+	"high-contrast": {
+		Colors:  ThemeColors{Completed: "#00FF00", Error: "#FF5555", Spinner: "#FFFF00", Muted: "#E0E0E0"},
+		Symbols: unicodeSymbols,
+	},
+	// the Okabe-Ito palette is distinguishable with the common forms of color blindness
+	"colorblind": {
+		Colors:  ThemeColors{Completed: "#0072B2", Error: "#E69F00", Spinner: "#CC79A7", Muted: "#999999"},
+		Symbols: unicodeSymbols,
+	},
+	"monochrome": {
+		Symbols: asciiSymbols,
+	},
+}
+
+// ThemeNames returns the names of the built-in themes.
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewTheme creates the Theme based on the built-in theme with the given name. The colors and symbols of the roles in
+// the given maps override the ones of the built-in theme. When noColor is true, the colors are removed (NO_COLOR).
+func NewTheme(name string, colors map[string]string, symbols map[string]string, noColor bool) (Theme, error) {
+	if len(name) == 0 {
+		name = DefaultTheme
+	}
+	theme, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q, the available themes are %v", name, ThemeNames())
+	}
+	colorRoles := map[string]*string{
+		"completed": &theme.Colors.Completed,
+		"error":     &theme.Colors.Error,
+		"spinner":   &theme.Colors.Spinner,
+		"muted":     &theme.Colors.Muted,
+	}
+	for role, color := range colors {
+		c, ok := colorRoles[role]
+		if !ok {
+			return Theme{}, fmt.Errorf("unknown color role %q", role)
+		}
+		*c = color
+	}
+	symbolRoles := map[string]*string{
+		"completed": &theme.Symbols.Completed,
+		"error":     &theme.Symbols.Error,
+		"skipped":   &theme.Symbols.Skipped,
+		"paused":    &theme.Symbols.Paused,
+		"branch":    &theme.Symbols.Branch,
+		"leaf":      &theme.Symbols.Leaf,
+		"divider":   &theme.Symbols.Divider,
+		"spinner":   &theme.Symbols.Spinner,
+	}
+	for role, symbol := range symbols {
+		s, ok := symbolRoles[role]
+		if !ok {
+			return Theme{}, fmt.Errorf("unknown symbol role %q", role)
+		}
+		*s = symbol
+	}
+	if noColor {
+		theme.Colors = ThemeColors{}
+	}
+	return theme, nil
+}
+
+// styles are the lipgloss styles of each role created from a Theme.
+type styles struct {
+	completed lipgloss.Style
+	error     lipgloss.Style
+	spinner   lipgloss.Style
+	muted     lipgloss.Style
+	selected  lipgloss.Style
+	detail    lipgloss.Style
+}
+
+func newStyles(theme Theme) styles {
+	return styles{
+		completed: newColorStyle(theme.Colors.Completed),
+		error:     newColorStyle(theme.Colors.Error),
+		spinner:   newColorStyle(theme.Colors.Spinner),
+		muted:     newColorStyle(theme.Colors.Muted),
+		selected:  lipgloss.NewStyle().Reverse(true),
+		detail: lipgloss.NewStyle().
+			BorderStyle(lipgloss.Border{Left: theme.Symbols.Divider}).
+			BorderLeft(true).
+			PaddingLeft(1),
+	}
+}
+
+func newColorStyle(color string) lipgloss.Style {
+	if len(color) == 0 {
+		return lipgloss.NewStyle()
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+}
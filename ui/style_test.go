@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewTheme(t *testing.T) {
+	tests := []struct {
+		name        string
+		themeName   string
+		colors      map[string]string
+		symbols     map[string]string
+		noColor     bool
+		expected    Theme
+		expectedErr bool
+	}{
+		{
+			name:     "Default",
+			expected: themes[DefaultTheme],
+		},
+		{
+			name:      "Built-in",
+			themeName: "monochrome",
+			expected:  Theme{Symbols: asciiSymbols},
+		},
+		{
+			name:      "Overrides",
+			themeName: "monochrome",
+			colors:    map[string]string{"error": "#FF00FF"},
+			symbols:   map[string]string{"completed": "ok"},
+			expected: Theme{
+				Colors: ThemeColors{Error: "#FF00FF"},
+				Symbols: func() ThemeSymbols {
+					s := asciiSymbols
+					s.Completed = "ok"
+					return s
+				}(),
+			},
+		},
+		{
+			name:     "No Color",
+			noColor:  true,
+			expected: Theme{Symbols: unicodeSymbols},
+		},
+		{
+			name:        "Unknown Theme",
+			themeName:   "foo",
+			expectedErr: true,
+		},
+		{
+			name:        "Unknown Role",
+			colors:      map[string]string{"foo": "#FF00FF"},
+			expectedErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := NewTheme(test.themeName, test.colors, test.symbols, test.noColor)
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
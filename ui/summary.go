@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// slowestCount is the number of the slowest repositories listed in the summary.
+const slowestCount = 5
+
+// getSummary returns the summary of the run as plain text, so the same summary can be rendered and written to a file.
+func getSummary(m *Model) string {
+	var b strings.Builder
+	completedCount, errorCount, skippedCount := m.countStates()
+	b.WriteString("Summary\n\n")
+	b.WriteString(fmt.Sprintf(
+		"Repositories - %d processed, %d skipped, %d failed\n",
+		completedCount,
+		skippedCount,
+		errorCount,
+	))
+	b.WriteString(fmt.Sprintf("Branches Deleted - %d\n", getTotalDeletedBranches(m.deletedBranches)))
+	strategies := getStrategyCounts(m.deletedBranches)
+	for _, s := range sortedStrategies(strategies) {
+		b.WriteString(fmt.Sprintf("   %s - %d\n", s, strategies[s]))
+	}
+	if reduced := getReducedConfidenceCount(m.deletedBranches); reduced > 0 {
+		b.WriteString(fmt.Sprintf("   %d with reduced confidence (shallow clones)\n", reduced))
+	}
+	b.WriteString(fmt.Sprintf("Time Taken - %s\n", getTimeTaken(m).Round(time.Millisecond)))
+
+	if slowest := getSlowestRepositories(m); len(slowest) > 0 {
+		b.WriteString("\nSlowest Repositories\n")
+		for _, position := range slowest {
+			b.WriteString(fmt.Sprintf(
+				"   %s - %s\n",
+				m.repositories[position].Name,
+				m.durations[position].Round(time.Millisecond),
+			))
+		}
+	}
+
+	if skippedCount > 0 {
+		b.WriteString("\nSkipped Repositories\n")
+		for i, r := range m.repositories {
+			if m.states[i] == skippedState {
+				b.WriteString(fmt.Sprintf("   %s: %s\n", r.Name, m.skipReasons[i]))
+			} else if m.states[i] == cancelledState {
+				b.WriteString(fmt.Sprintf("   %s: cancelled\n", r.Name))
+			}
+		}
+	}
+
+	if errorCount > 0 {
+		b.WriteString("\nErrors\n")
+		groups := getErrorGroups(m)
+		for _, t := range sortedErrorTypes(groups) {
+			b.WriteString(fmt.Sprintf("   %s (%d)\n", t, len(groups[t])))
+			for _, message := range groups[t] {
+				b.WriteString(fmt.Sprintf("      %s\n", message))
+			}
+		}
+	}
+	return b.String()
+}
+
+// getTimeTaken returns the time taken to process the repositories. When the processing was stopped early, the time
+// taken so far is returned.
+func getTimeTaken(m *Model) time.Duration {
+	if m.startTime.IsZero() {
+		return 0
+	}
+	if m.endTime.IsZero() {
+		return time.Since(m.startTime)
+	}
+	return m.endTime.Sub(m.startTime)
+}
+
+func getReducedConfidenceCount(deleted map[int][]deletedBranch) int {
+	var count int
+	for _, branches := range deleted {
+		for _, branch := range branches {
+			if branch.reducedConfidence {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func getStrategyCounts(deleted map[int][]deletedBranch) map[strategy]int {
+	counts := make(map[strategy]int)
+	for _, branches := range deleted {
+		for _, branch := range branches {
+			counts[branch.strategy]++
+		}
+	}
+	return counts
+}
+
+func sortedStrategies(counts map[strategy]int) []strategy {
+	strategies := make([]strategy, 0, len(counts))
+	for s := range counts {
+		strategies = append(strategies, s)
+	}
+	sort.Slice(strategies, func(i, j int) bool {
+		return strategies[i] < strategies[j]
+	})
+	return strategies
+}
+
+// getSlowestRepositories returns the positions of the slowest repositories, slowest first.
+func getSlowestRepositories(m *Model) []int {
+	positions := make([]int, 0, len(m.durations))
+	for position := range m.durations {
+		positions = append(positions, position)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if m.durations[positions[i]] == m.durations[positions[j]] {
+			return positions[i] < positions[j]
+		}
+		return m.durations[positions[i]] > m.durations[positions[j]]
+	})
+	if len(positions) > slowestCount {
+		positions = positions[:slowestCount]
+	}
+	return positions
+}
+
+// getErrorGroups returns the error messages of all repositories grouped by the type of the error.
+func getErrorGroups(m *Model) map[errorType][]string {
+	groups := make(map[errorType][]string)
+	for i, r := range m.repositories {
+		for _, err := range m.errMessages[i] {
+			t := errorType("Other")
+			var pErr processError
+			if errors.As(err, &pErr) {
+				t = pErr.errorType
+			}
+			groups[t] = append(groups[t], fmt.Sprintf("%s: %s", r.Name, err))
+		}
+	}
+	return groups
+}
+
+func sortedErrorTypes(groups map[errorType][]string) []errorType {
+	types := make([]errorType, 0, len(groups))
+	for t := range groups {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i] < types[j]
+	})
+	return types
+}
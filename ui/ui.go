@@ -2,85 +2,204 @@ package ui
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"golang.org/x/sync/semaphore"
+	"github.com/charmbracelet/lipgloss"
+	"io"
+	"log/slog"
+	"lopper/config"
+	"lopper/discovery"
 	"lopper/git"
-	"lopper/utils"
-	"os"
+	"lopper/logging"
+	"lopper/provider"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // Model is the model for the UI.
 type Model struct {
 	// configuration properties
-	path              string
-	protectedBranches []string
-	dryRun            bool
+	paths              []string
+	projects           []discovery.Project
+	protectedBranches  []string
+	dryRun             bool
+	pruneWorktrees     bool
+	recurseSubmodules  bool
+	trunks             []config.Trunk
+	trunkRemote        string
+	pruneRemote        string
+	deepen             int
+	shallowSince       string
+	deleteGoneBranches bool
+	preDeleteHook      string
+	postDeleteHook     string
+	providers          map[string]provider.Provider
+	logger             *slog.Logger
+	output             io.Writer
+	theme              Theme
 
 	// state properties
 	repositories    []git.Repository
 	states          map[int]state
-	deletedBranches map[int][]string
+	deletedBranches map[int][]deletedBranch
+	skippedBranches map[int][]skippedBranch
+	skipReasons     map[int]string
+	warnings        map[int][]string
 	errMessages     map[int][]error
+	durations       map[int]time.Duration
+	repoTrunks      map[int][]string
+	clones          map[int]clone
+	commands        *commandLog
+	startTime       time.Time
+	endTime         time.Time
 
 	// view properties
-	spinner  spinner.Model
-	viewport viewport.Model
-	builder  strings.Builder
+	styles      styles
+	spinner     spinner.Model
+	viewport    viewport.Model
+	detail      viewport.Model
+	builder     strings.Builder
+	width       int
+	showSummary bool
+	showDetail  bool
+	showHelp    bool
+	help        help.Model
+	keys        KeyMap
+	cursor      int
+	repoLines   []int
+	filter      filter
 
 	// other properties
 	ready            bool
-	semaphore        *semaphore.Weighted
+	limiter          *limiter
+	paused           bool
+	cancels          map[int]context.CancelFunc
+	cancelled        map[int]bool
 	startProcessMsgs chan inprocessMsg
 	completedMsgs    chan completedMsg
 	err              error
 }
 
+// mouseWheelDelta is the number of lines scrolled by a turn of the mouse wheel.
+const mouseWheelDelta = 3
+
 type state int
 
 const (
 	inprogressState state = iota
 	completedState
 	errorState
+	skippedState
+	pausedState
+	cancelledState
 )
 
 // NewModel creates a new Model.
 func NewModel(options ...Option) *Model {
 	m := &Model{
 		states:          make(map[int]state),
-		deletedBranches: make(map[int][]string),
+		deletedBranches: make(map[int][]deletedBranch),
+		skippedBranches: make(map[int][]skippedBranch),
+		skipReasons:     make(map[int]string),
+		warnings:        make(map[int][]string),
 		errMessages:     make(map[int][]error),
-		spinner:         newSpinner(),
+		durations:       make(map[int]time.Duration),
+		repoTrunks:      make(map[int][]string),
+		clones:          make(map[int]clone),
+		cancels:         make(map[int]context.CancelFunc),
+		cancelled:       make(map[int]bool),
+		commands:        &commandLog{commands: make(map[string][]git.Command)},
+		filter:          filter{search: newSearch()},
+		theme:           themes[DefaultTheme],
+		keys:            DefaultKeyMap(),
+		logger:          logging.Discard(),
 	}
 	for _, option := range options {
 		option(m)
 	}
+	m.styles = newStyles(m.theme)
+	m.spinner = newSpinner(m.theme, m.styles)
+	m.help = newHelp(m.styles)
 	return m
 }
 
-func newSpinner() spinner.Model {
+func newSpinner(theme Theme, styles styles) spinner.Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = spinnerColor
+	// like spinner.Dot, each frame is followed by a space
+	s.Spinner.Frames = nil
+	for _, frame := range theme.Symbols.Spinner {
+		s.Spinner.Frames = append(s.Spinner.Frames, string(frame)+" ")
+	}
+	s.Style = styles.spinner
 	return s
 }
 
+func newHelp(styles styles) help.Model {
+	h := help.New()
+	// the colors of the help follow the theme
+	h.Styles.ShortKey = lipgloss.NewStyle()
+	h.Styles.ShortDesc = styles.muted
+	h.Styles.ShortSeparator = styles.muted
+	h.Styles.Ellipsis = styles.muted
+	h.Styles.FullKey = lipgloss.NewStyle()
+	h.Styles.FullDesc = styles.muted
+	h.Styles.FullSeparator = styles.muted
+	return h
+}
+
 // Error returns the error that occurred during the execution of the UI.
 func (m *Model) Error() error {
 	return m.err
 }
 
+// Result is the outcome of processing the repositories.
+type Result struct {
+	// Processed is the number of repositories that have been processed successfully, like in the summary.
+	Processed int
+	// Skipped is the number of repositories that were skipped (e.g. the main branch does not exist).
+	Skipped int
+	// Failed is the number of repositories that failed to be processed.
+	Failed int
+	// Branches is the number of branches deleted, or the number of branches that would be deleted on a dry run.
+	Branches int
+}
+
+// Summary returns the summary of the run as plain text.
+func (m *Model) Summary() string {
+	return getSummary(m)
+}
+
+// Result returns the outcome of processing the repositories.
+func (m *Model) Result() Result {
+	completedCount, errorCount, skippedCount := m.countStates()
+	return Result{
+		Processed: completedCount,
+		Skipped:   skippedCount,
+		Failed:    errorCount,
+		Branches:  getTotalDeletedBranches(m.deletedBranches),
+	}
+}
+
 func (m *Model) Init() tea.Cmd {
+	// the spinner is only rendered by the TUI
+	var tick tea.Cmd
+	if !m.isPlain() {
+		tick = spinner.Tick
+		// record the Git commands executed for each repository to show them in the detail pane. Observers cannot be
+		// removed, so they are not recorded in plain mode, where a new Model is created for every run of the watch mode.
+		git.AddObserver(m.commands.record)
+	}
 	return tea.Batch(
 		// start the ticking of the spinner
-		spinner.Tick,
+		tick,
 		// load all the repos
-		loadRepositories(m.path),
+		loadRepositories(m.paths, m.projects, m.recurseSubmodules),
 		// handle the first inprocess message
 		startProcess(m.startProcessMsgs),
 		// handle the first completed message
@@ -88,93 +207,198 @@ func (m *Model) Init() tea.Cmd {
 	)
 }
 
-func loadRepositories(path string) tea.Cmd {
+func loadRepositories(paths []string, projects []discovery.Project, recurseSubmodules bool) tea.Cmd {
 	return func() tea.Msg {
-		repositories, err := getRepositories(path)
+		repositories, warnings, err := discovery.GetRepositories(context.Background(), paths, projects, recurseSubmodules)
 		if err != nil {
 			return errorMsg{err}
 		}
-		return repositoriesMsg{repositories}
+		return repositoriesMsg{repositories: repositories, warnings: warnings}
 	}
 }
 
-func getRepositories(path string) ([]git.Repository, error) {
-	var repositories []git.Repository
-	// check if the path given is a repository
-	if git.IsGitRepository(path) {
-		absPath, err := filepath.Abs(path)
-		if err != nil {
-			return nil, err
-		}
-		repositories = append(repositories, git.Repository{Path: filepath.Dir(absPath), Name: filepath.Base(absPath)})
-	} else {
-		// else the path is a directory of containing repositories
-		dir, err := os.ReadDir(path)
-		if err != nil {
-			return repositories, err
-		}
-		for _, entry := range dir {
-			// only consider directories that are git repositories
-			if entry.IsDir() && git.IsGitRepository(filepath.Join(path, entry.Name())) {
-				repositories = append(repositories, git.Repository{Path: path, Name: entry.Name()})
-			}
-		}
-	}
-	return repositories, nil
-}
-
 // Update updates the Model and allows the View to be able to be updated.
 //
 // The message flow is as follows:
-// 1. Model.Init is called. This starts the loading process of all repositories and allows the initial inprocess and
-//    completed messages to be handled.
-// 2. Once the repositoriesMsg is received, the repositories are loaded in the Model and processRepos is called to
-//    start processing all repositories.
-// 3. Once a inprocessMsg is received, the the Model.inprogress map is updated to allow the view to reelect the process.
-//    Then the processing of repositories is started, and handling of the next inprocessMsg is enabled.
-// 4. Once a completedMsg is received, the Model is updated based on the start (completed or error), and handling of
-//    the next completedMsg is enabled.
+//  1. Model.Init is called. This starts the loading process of all repositories and allows the initial inprocess and
+//     completed messages to be handled.
+//  2. Once the repositoriesMsg is received, the repositories are loaded in the Model and processRepos is called to
+//     start processing all repositories.
+//  3. Once a inprocessMsg is received, the the Model.inprogress map is updated to allow the view to reelect the process.
+//     Then the processing of repositories is started, and handling of the next inprocessMsg is enabled.
+//  4. Once a completedMsg is received, the Model is updated based on the start (completed or error), and handling of
+//     the next completedMsg is enabled.
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	// Handle error messages. Immediately quits the programs.
 	case errorMsg:
 		m.err = msg.err
-		return nil, tea.Quit
+		return m, tea.Quit
 	// Handle loading repositories. Triggers the processing of the repositories.
 	case repositoriesMsg:
 		m.repositories = msg.repositories
+		m.startTime = time.Now()
+		for _, warning := range msg.warnings {
+			if m.isPlain() {
+				m.printf("warning: %s", warning)
+			}
+			m.logger.Warn("failed to discover submodules", slog.String("error", warning.Error()))
+		}
+		if m.isPlain() && len(m.repositories) == 0 {
+			m.printf("There are no repositories in this directory.")
+			return m, tea.Quit
+		}
 		return m, m.processRepos()
 	// Handle starting the process of a repository. Updates the Model and starts the processing of the specific
 	// repository and enables the receiving of the next inprocess message.
 	case inprocessMsg:
 		m.states[msg.position] = inprogressState
+		if m.isPlain() {
+			m.printStarted(msg.position)
+		}
+		m.logger.Debug("processing repository", slog.String("repository", filepath.Join(msg.repository.Path, msg.repository.Name)))
+		// keep the cancel function to be able to cancel the processing of the repository
+		ctx, cancel := context.WithCancel(context.Background())
+		m.cancels[msg.position] = cancel
 		// use tea.Batch to start multiple commands in parallel
 		return m, tea.Batch(
-			m.processRepo(msg.position, msg.repository),
+			m.processRepo(ctx, msg.position, msg.repository),
 			startProcess(m.startProcessMsgs),
 		)
 	// Handle completing the process of a repository. Updates the model, allows the next repo to be processed and
 	// enables receiving of the next completed message.
 	case completedMsg:
-		if msg.errs != nil {
+		m.cancels[msg.position]()
+		delete(m.cancels, msg.position)
+		if m.cancelled[msg.position] {
+			m.states[msg.position] = cancelledState
+		} else if len(msg.skipReason) > 0 {
+			m.states[msg.position] = skippedState
+		} else if msg.errs != nil {
 			m.states[msg.position] = errorState
 		} else {
 			m.states[msg.position] = completedState
 		}
 		m.deletedBranches[msg.position] = msg.branches
+		m.skippedBranches[msg.position] = msg.skipped
+		m.skipReasons[msg.position] = msg.skipReason
+		m.warnings[msg.position] = msg.warnings
 		m.errMessages[msg.position] = msg.errs
+		if m.cancelled[msg.position] {
+			// the errors are caused by killing the Git commands of the cancelled repository
+			m.errMessages[msg.position] = nil
+		}
+		m.durations[msg.position] = msg.duration
+		m.repoTrunks[msg.position] = msg.trunks
+		m.clones[msg.position] = msg.clone
+		m.logCompleted(msg)
 		// allow the next repo to be processed
-		m.semaphore.Release(1)
+		m.limiter.release()
+		if m.isDone() {
+			m.endTime = time.Now()
+			// switch to the summary once the last repository has been processed
+			m.showSummary = true
+		}
+		if m.isPlain() {
+			m.printCompleted(msg)
+			// there is no one to quit the plain mode, so quit once all repositories have been processed
+			if m.isDone() {
+				m.printSummary()
+				return m, tea.Quit
+			}
+		}
 		return m, completeRepo(m.completedMsgs)
 	// Handle key presses.
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		// while searching, the keys are typed into the search
+		if m.filter.search.Focused() && msg.String() != "ctrl+c" {
+			return m, m.updateSearch(msg)
+		}
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		switch {
+		case key.Matches(msg, m.keys.Quit):
 			return m, tea.Quit
-		case "up", "j":
+		case key.Matches(msg, m.keys.Help):
+			m.showHelp = !m.showHelp
+			m.viewport.GotoTop()
+		case key.Matches(msg, m.keys.Up):
 			m.viewport.LineUp(1)
-		case "down", "k":
+		case key.Matches(msg, m.keys.Down):
 			m.viewport.LineDown(1)
+		case key.Matches(msg, m.keys.PageUp):
+			m.viewport.ViewUp()
+		case key.Matches(msg, m.keys.PageDown):
+			m.viewport.ViewDown()
+		case key.Matches(msg, m.keys.Home):
+			m.viewport.GotoTop()
+		case key.Matches(msg, m.keys.End):
+			m.viewport.GotoBottom()
+		case key.Matches(msg, m.keys.Summary):
+			// the summary is only available once all repositories have been processed
+			if m.isDone() {
+				m.showSummary = !m.showSummary
+				m.showHelp = false
+				m.viewport.GotoTop()
+			}
+		case key.Matches(msg, m.keys.Next):
+			m.moveCursor(1)
+		case key.Matches(msg, m.keys.Previous):
+			m.moveCursor(-1)
+		case key.Matches(msg, m.keys.Retry):
+			if len(m.repositories) > 0 && (m.states[m.cursor] == errorState || m.states[m.cursor] == cancelledState) {
+				return m, m.retryRepos([]int{m.cursor})
+			}
+		case key.Matches(msg, m.keys.RetryAll):
+			return m, m.retryRepos(m.getFailedPositions())
+		case key.Matches(msg, m.keys.Detail):
+			m.showDetail = !m.showDetail
+			m.showSummary = false
+			m.showHelp = false
+			m.resize()
+			m.detail.GotoTop()
+		case key.Matches(msg, m.keys.DetailUp):
+			m.detail.LineUp(1)
+		case key.Matches(msg, m.keys.DetailDown):
+			m.detail.LineDown(1)
+		case key.Matches(msg, m.keys.Search):
+			m.showSummary = false
+			m.showHelp = false
+			return m, m.filter.search.Focus()
+		case key.Matches(msg, m.keys.OnlyFailed):
+			m.filter.onlyFailed = !m.filter.onlyFailed
+			m.selectVisible()
+		case key.Matches(msg, m.keys.OnlyDeleted):
+			m.filter.onlyDeleted = !m.filter.onlyDeleted
+			m.selectVisible()
+		case key.Matches(msg, m.keys.HideUntouched):
+			m.filter.hideUntouched = !m.filter.hideUntouched
+			m.selectVisible()
+		case key.Matches(msg, m.keys.ClearFilter):
+			// escaping the help overlay takes precedence over clearing the filters
+			if m.showHelp {
+				m.showHelp = false
+			} else {
+				m.clearFilter()
+			}
+		case key.Matches(msg, m.keys.Pause):
+			m.setPaused(!m.paused)
+		case key.Matches(msg, m.keys.Cancel):
+			m.cancelRepo(m.cursor)
+		case key.Matches(msg, m.keys.IncreaseConcurrency):
+			m.limiter.setLimit(m.limiter.getLimit() + 1)
+		case key.Matches(msg, m.keys.DecreaseConcurrency):
+			m.limiter.setLimit(m.limiter.getLimit() - 1)
+		}
+		return m, nil
+	// Handle mouse wheel scrolling.
+	case tea.MouseMsg:
+		switch msg.Type {
+		case tea.MouseWheelUp:
+			m.viewport.LineUp(mouseWheelDelta)
+		case tea.MouseWheelDown:
+			m.viewport.LineDown(mouseWheelDelta)
 		}
 		return m, nil
 	// Handle spinner ticks.
@@ -185,12 +409,16 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Handle window resize.
 	case tea.WindowSizeMsg:
 		if !m.ready {
-			m.viewport = viewport.Model{Width: msg.Width, Height: msg.Height - 7}
+			m.viewport = viewport.Model{Width: msg.Width, Height: msg.Height - 6}
+			m.detail = viewport.Model{Width: msg.Width, Height: msg.Height - 6, Style: m.styles.detail}
 			m.ready = true
 		} else {
-			m.viewport.Width = msg.Width
-			m.viewport.Height = msg.Height - 7
+			m.viewport.Height = msg.Height - 6
+			m.detail.Height = msg.Height - 6
 		}
+		m.width = msg.Width
+		m.help.Width = msg.Width
+		m.resize()
 		return m, nil
 	default:
 		return m, nil
@@ -198,81 +426,103 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) processRepos() tea.Cmd {
+	positions := make([]int, len(m.repositories))
+	for i := range m.repositories {
+		positions[i] = i
+	}
+	return m.dispatchRepos(positions)
+}
+
+// dispatchRepos starts the processing of the repositories at the given positions.
+func (m *Model) dispatchRepos(positions []int) tea.Cmd {
 	return func() tea.Msg {
-		for i, r := range m.repositories {
+		for _, position := range positions {
 			// limit the number of processes that can process repos
-			if err := m.semaphore.Acquire(context.Background(), 1); err != nil {
-				return errorMsg{err}
-			}
-			m.startProcessMsgs <- inprocessMsg{position: i, repository: r}
+			m.limiter.acquire()
+			m.startProcessMsgs <- inprocessMsg{position: position, repository: m.repositories[position]}
 		}
 		return nil
 	}
 }
 
+// retryRepos processes the failed repositories at the given positions again.
+func (m *Model) retryRepos(positions []int) tea.Cmd {
+	if len(positions) == 0 {
+		return nil
+	}
+	for _, position := range positions {
+		// the repository is pending again until it is picked up to be processed
+		delete(m.states, position)
+		delete(m.deletedBranches, position)
+		delete(m.skippedBranches, position)
+		delete(m.skipReasons, position)
+		delete(m.warnings, position)
+		delete(m.errMessages, position)
+		delete(m.durations, position)
+		delete(m.repoTrunks, position)
+		delete(m.clones, position)
+		delete(m.cancelled, position)
+		m.commands.clear(m.repositories[position])
+	}
+	// go back to the list to show the progress of the retried repositories
+	m.showSummary = false
+	m.endTime = time.Time{}
+	return m.dispatchRepos(positions)
+}
+
+// setPaused pauses or resumes the processing of the repositories that have not been started yet.
+func (m *Model) setPaused(paused bool) {
+	m.paused = paused
+	m.limiter.setPaused(paused)
+	// mark the pending repositories, so it is visible that they are not going to be started
+	for i := range m.repositories {
+		if _, ok := m.states[i]; paused && !ok {
+			m.states[i] = pausedState
+		} else if !paused && m.states[i] == pausedState {
+			delete(m.states, i)
+		}
+	}
+}
+
+// cancelRepo cancels the processing of the repository at the given position if it is in progress.
+func (m *Model) cancelRepo(position int) {
+	cancel, ok := m.cancels[position]
+	if !ok {
+		return
+	}
+	m.cancelled[position] = true
+	cancel()
+}
+
+// getFailedPositions returns the positions of the repositories that failed to be processed.
+func (m *Model) getFailedPositions() []int {
+	var positions []int
+	for i := range m.repositories {
+		if m.states[i] == errorState {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
 func startProcess(startProcessMsgs chan inprocessMsg) tea.Cmd {
 	return func() tea.Msg {
 		return <-startProcessMsgs
 	}
 }
 
-func (m *Model) processRepo(position int, repo git.Repository) tea.Cmd {
+func (m *Model) processRepo(ctx context.Context, position int, repo git.Repository) tea.Cmd {
 	return func() tea.Msg {
 		go func() {
-			branches, errs := process(repo, m.protectedBranches, m.dryRun)
-			m.completedMsgs <- completedMsg{position: position, branches: branches, errs: errs}
+			start := time.Now()
+			result := m.process(ctx, repo)
+			result.duration = time.Since(start)
+			m.completedMsgs <- completedMsg{position: position, processResult: result}
 		}()
 		return nil
 	}
 }
 
-func process(repo git.Repository, protectedBranches []string, dryRun bool) ([]string, []error) {
-	fullPath := filepath.Join(repo.Path, repo.Name)
-	// Default to "main" branch. If there is an error, will assume the repo's main branch is "master" and try again.
-	mainBranch := "main"
-	if err := git.CheckoutBranch(fullPath, mainBranch); err != nil {
-		mainBranch = "master"
-		if err = git.CheckoutBranch(fullPath, mainBranch); err != nil {
-			return nil, []error{errors.New("the main branch has not been checked out locally")}
-		}
-	}
-	// ensure everything is up to date so we know for sure which branches are dead (merged)
-	if err := git.Pull(fullPath); err != nil {
-		return nil, []error{err}
-	}
-	// get all branches that have been merged into the main branch
-	mergedBranches, err := git.GetMergedBranches(fullPath, mainBranch)
-	if err != nil {
-		return nil, []error{err}
-	}
-	squashedBranches, err := git.GetMergedSquashedBranches(fullPath, mainBranch, mergedBranches)
-	if err != nil {
-		return nil, []error{err}
-	}
-	mergedBranches = append(mergedBranches, squashedBranches...)
-
-	var branches []string
-	var errs []error
-	for _, branch := range mergedBranches {
-		// skip protected branches
-		if !utils.Contains(protectedBranches, branch) {
-			// if a dry run, just add the branch to the list of deleted branches
-			if dryRun {
-				branches = append(branches, branch)
-			} else {
-				// try to delete the branch
-				if err = git.DeleteBranch(fullPath, branch); err != nil {
-					errs = append(errs, err)
-				} else {
-					// if successful, add the branch to the list of deleted branches
-					branches = append(branches, branch)
-				}
-			}
-		}
-	}
-	return branches, errs
-}
-
 func completeRepo(completedMsgs chan completedMsg) tea.Cmd {
 	return func() tea.Msg {
 		return <-completedMsgs
@@ -282,14 +532,24 @@ func completeRepo(completedMsgs chan completedMsg) tea.Cmd {
 func (m *Model) View() string {
 	var body string
 	if m.ready && len(m.repositories) > 0 {
-		m.viewport.SetContent(getBody(m))
+		if m.showHelp {
+			m.viewport.SetContent(m.help.FullHelpView(m.keys.FullHelp()))
+		} else if m.showSummary {
+			m.viewport.SetContent(getSummary(m))
+		} else {
+			m.viewport.SetContent(getBody(m))
+		}
 		body = m.viewport.View()
+		if m.showDetail && !m.showSummary && !m.showHelp {
+			m.detail.SetContent(getDetail(m))
+			body = lipgloss.JoinHorizontal(lipgloss.Top, body, m.detail.View())
+		}
 	}
 	return fmt.Sprintf(
 		"%s\n\n%s\n%s",
 		getHeader(m),
 		body,
-		grayStyle.Render(getFooter(m)),
+		m.styles.muted.Render(getFooter(m)),
 	)
 }
 
@@ -299,24 +559,45 @@ func getHeader(m *Model) string {
 	} else if len(m.repositories) == 0 {
 		return "There are no repositories in this directory."
 	} else {
-		completedCount := 0
-		errorCount := 0
-		for _, s := range m.states {
-			if s == completedState {
-				completedCount++
-			} else if s == errorState {
-				errorCount++
-			}
+		completedCount, errorCount, skippedCount := m.countStates()
+		status := fmt.Sprintf("Concurrency - %d", m.limiter.getLimit())
+		if m.paused {
+			status += " (paused)"
 		}
 		return fmt.Sprintf(
-			"%s\n%s",
-			fmt.Sprintf("Repositories (%d/%d)", completedCount+errorCount, len(m.repositories)),
-			grayStyle.Render(fmt.Sprintf("Branches Deleted - %d", getTotalDeletedBranches(m.deletedBranches))),
+			"%s  %s\n%s",
+			fmt.Sprintf("Repositories (%d/%d)", completedCount+errorCount+skippedCount, len(m.repositories)),
+			m.styles.muted.Render(status),
+			m.styles.muted.Render(fmt.Sprintf("Branches Deleted - %d", getTotalDeletedBranches(m.deletedBranches))),
 		)
 	}
 }
 
-func getTotalDeletedBranches(deleted map[int][]string) int {
+// countStates returns the number of repositories that have completed, that have failed and that have been skipped.
+// Cancelled repositories are counted as skipped.
+func (m *Model) countStates() (int, int, int) {
+	completedCount := 0
+	errorCount := 0
+	skippedCount := 0
+	for _, s := range m.states {
+		if s == completedState {
+			completedCount++
+		} else if s == errorState {
+			errorCount++
+		} else if s == skippedState || s == cancelledState {
+			skippedCount++
+		}
+	}
+	return completedCount, errorCount, skippedCount
+}
+
+// isDone returns true when all repositories have been processed.
+func (m *Model) isDone() bool {
+	completedCount, errorCount, skippedCount := m.countStates()
+	return m.repositories != nil && completedCount+errorCount+skippedCount == len(m.repositories)
+}
+
+func getTotalDeletedBranches(deleted map[int][]deletedBranch) int {
 	var total int
 	for _, branches := range deleted {
 		total += len(branches)
@@ -327,40 +608,78 @@ func getTotalDeletedBranches(deleted map[int][]string) int {
 func getBody(m *Model) string {
 	defer m.builder.Reset()
 
+	m.repoLines = m.repoLines[:0]
 	for i, r := range m.repositories {
+		if !m.isVisible(i) {
+			m.repoLines = append(m.repoLines, -1)
+			continue
+		}
+		// track the line of each repository so the viewport can follow the cursor
+		m.repoLines = append(m.repoLines, strings.Count(m.builder.String(), "\n"))
+		// submodules are indented under their parent repository
+		indent := strings.Repeat("   ", r.Depth)
+		name := r.Name
+		if i == m.cursor {
+			name = m.styles.selected.Render(name)
+		}
 		if m.states[i] == inprogressState {
-			m.builder.WriteString(fmt.Sprintf("%s %s\n", m.spinner.View(), r.Name))
+			m.builder.WriteString(fmt.Sprintf("%s%s %s\n", indent, m.spinner.View(), name))
 		} else if m.states[i] == completedState {
-			m.builder.WriteString(fmt.Sprintf("%s  %s\n", completedStyle.Render(symbolCheck), r.Name))
+			m.builder.WriteString(fmt.Sprintf("%s%s  %s\n", indent, m.styles.completed.Render(m.theme.Symbols.Completed), name))
 		} else if m.states[i] == errorState {
-			m.builder.WriteString(fmt.Sprintf("%s  %s\n", errorStyle.Render(symbolX), r.Name))
+			m.builder.WriteString(fmt.Sprintf("%s%s  %s\n", indent, m.styles.error.Render(m.theme.Symbols.Error), name))
+		} else if m.states[i] == skippedState {
+			m.builder.WriteString(fmt.Sprintf("%s%s  %s\n", indent, m.styles.muted.Render(m.theme.Symbols.Skipped), name))
+			m.builder.WriteString(fmt.Sprintf("%s   %s %s\n", indent, m.styles.muted.Render(m.theme.Symbols.Leaf), m.styles.muted.Render(m.skipReasons[i])))
+		} else if m.states[i] == pausedState {
+			m.builder.WriteString(fmt.Sprintf("%s%s  %s\n", indent, m.styles.muted.Render(m.theme.Symbols.Paused), name))
+		} else if m.states[i] == cancelledState {
+			m.builder.WriteString(fmt.Sprintf("%s%s  %s %s\n", indent, m.styles.muted.Render(m.theme.Symbols.Error), name, m.styles.muted.Render("(cancelled)")))
 		} else {
-			m.builder.WriteString(fmt.Sprintf("%s  %s\n", " ", r.Name))
+			m.builder.WriteString(fmt.Sprintf("%s%s  %s\n", indent, " ", name))
 		}
-		for j, deletedBranch := range m.deletedBranches[i] {
-			if j == len(m.deletedBranches[i])-1 {
-				m.builder.WriteString(fmt.Sprintf("   %s %s\n", grayStyle.Render(symbolLeaf), grayStyle.Render(deletedBranch)))
-			} else {
-				m.builder.WriteString(fmt.Sprintf("   %s %s\n", grayStyle.Render(symbolBranch), grayStyle.Render(deletedBranch)))
-			}
+		// the branches and errors are drawn as a tree, where only the last child of the repository is a leaf
+		var children []treeChild
+		for _, deletedBranch := range m.getVisibleDeletedBranches(i) {
+			children = append(children, treeChild{style: m.styles.muted, text: deletedBranch.String()})
 		}
-		for j, err := range m.errMessages[i] {
-			if j == len(m.errMessages[i])-1 {
-				m.builder.WriteString(fmt.Sprintf("   %s %s\n", errorStyle.Render(symbolLeaf), errorStyle.Render(err.Error())))
-			} else {
-				m.builder.WriteString(fmt.Sprintf("   %s %s\n", errorStyle.Render(symbolBranch), errorStyle.Render(err.Error())))
+		for _, skipped := range m.getVisibleSkippedBranches(i) {
+			children = append(children, treeChild{style: m.styles.muted, text: skipped.String()})
+		}
+		for _, warning := range m.warnings[i] {
+			children = append(children, treeChild{style: m.styles.muted, text: "warning: " + warning})
+		}
+		for _, err := range m.errMessages[i] {
+			children = append(children, treeChild{style: m.styles.error, text: err.Error()})
+		}
+		for j, child := range children {
+			symbol := m.theme.Symbols.Branch
+			if j == len(children)-1 {
+				symbol = m.theme.Symbols.Leaf
 			}
+			m.builder.WriteString(fmt.Sprintf("%s   %s %s\n", indent, child.style.Render(symbol), child.style.Render(child.text)))
 		}
 	}
 
 	return m.builder.String()
 }
 
+// treeChild is a line drawn under a repository in the tree of repositories.
+type treeChild struct {
+	style lipgloss.Style
+	text  string
+}
+
 func getFooter(m *Model) string {
-	return fmt.Sprintf(
-		"\n%s\n%s\n%s",
-		fmt.Sprintf("Scroll: %3.f%%", m.viewport.ScrollPercent()*100),
-		"(press '↑' or '↓' to scroll)",
-		"(press 'q' to quit)",
-	)
+	scroll := fmt.Sprintf("Scroll: %3.f%%", m.viewport.ScrollPercent()*100)
+	if status := m.getFilterStatus(); len(status) > 0 {
+		scroll = fmt.Sprintf("%s  Filter: %s", scroll, status)
+	}
+	bindings := m.keys.ShortHelp()
+	if m.isDone() {
+		bindings = append(bindings[:len(bindings)-2], m.keys.Summary, m.keys.Help, m.keys.Quit)
+	} else {
+		bindings = append(bindings[:len(bindings)-2], m.keys.Pause, m.keys.Help, m.keys.Quit)
+	}
+	return fmt.Sprintf("\n%s\n%s", scroll, m.help.ShortHelpView(bindings))
 }
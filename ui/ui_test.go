@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"errors"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lopper/git"
+	"testing"
+	"time"
+)
+
+func TestGetBody(t *testing.T) {
+	m := NewModel()
+	m.repositories = []git.Repository{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	m.cursor = -1
+	m.states[0] = completedState
+	m.deletedBranches[0] = []deletedBranch{{name: "feature"}, {name: "fix"}}
+	m.skippedBranches[0] = []skippedBranch{{name: "docs", reason: "checked out in worktree /tmp/docs"}}
+	m.states[1] = errorState
+	m.deletedBranches[1] = []deletedBranch{{name: "feature"}}
+	m.errMessages[1] = []error{errors.New("failed to delete branch")}
+	m.states[2] = completedState
+	m.deletedBranches[2] = []deletedBranch{{name: "feature"}}
+
+	// only the last child of each repository is a leaf, regardless of the kind of the children before it
+	assert.Equal(
+		t,
+		"✔  a\n"+
+			"   ├ feature\n"+
+			"   ├ fix\n"+
+			"   └ docs (skipped: checked out in worktree /tmp/docs)\n"+
+			"✘  b\n"+
+			"   ├ feature\n"+
+			"   └ failed to delete branch\n"+
+			"✔  c\n"+
+			"   └ feature\n",
+		getBody(m),
+	)
+}
+
+func TestRetryRepos(t *testing.T) {
+	m := NewModel(Concurrency(2))
+	m.repositories = []git.Repository{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	m.states[0] = completedState
+	m.deletedBranches[0] = []deletedBranch{{name: "feature"}}
+	for _, position := range []int{1, 2} {
+		m.states[position] = errorState
+		m.deletedBranches[position] = []deletedBranch{{name: "feature"}}
+		m.skippedBranches[position] = []skippedBranch{{name: "docs", reason: "checked out"}}
+		m.warnings[position] = []string{"squash merges into main could not be detected"}
+		m.errMessages[position] = []error{errors.New("failed to delete branch fix")}
+		m.durations[position] = time.Second
+		m.repoTrunks[position] = []string{"main"}
+		m.clones[position] = shallowClone
+	}
+	m.showSummary = true
+	m.endTime = time.Now()
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	require.NotNil(t, cmd)
+	cmd()
+
+	// the failed repositories are queued to be processed again
+	assert.Equal(t, inprocessMsg{position: 1, repository: m.repositories[1]}, <-m.startProcessMsgs)
+	assert.Equal(t, inprocessMsg{position: 2, repository: m.repositories[2]}, <-m.startProcessMsgs)
+	// and are pending again, without the outcome of the failed run
+	for _, position := range []int{1, 2} {
+		assert.NotContains(t, m.states, position)
+		assert.NotContains(t, m.deletedBranches, position)
+		assert.NotContains(t, m.skippedBranches, position)
+		assert.NotContains(t, m.warnings, position)
+		assert.NotContains(t, m.errMessages, position)
+		assert.NotContains(t, m.durations, position)
+		assert.NotContains(t, m.repoTrunks, position)
+		assert.NotContains(t, m.clones, position)
+	}
+	// the completed repository is left alone
+	assert.Equal(t, completedState, m.states[0])
+	assert.Equal(t, []deletedBranch{{name: "feature"}}, m.deletedBranches[0])
+	assert.False(t, m.showSummary)
+	assert.True(t, m.endTime.IsZero())
+}
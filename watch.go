@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"lopper/ui"
+	"lopper/watch"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// watchFlags returns the flags of the watch mode, which processes the repositories periodically.
+func watchFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "every",
+			Usage: "processes the repositories every given interval (e.g. 24h) as plain text until interrupted, instead of once",
+		},
+		&cli.StringFlag{
+			Name:  "quiet-hours",
+			Usage: "postpones the runs of --every that fall in the given daily window in local time (e.g. 22:00-07:00)",
+		},
+		&cli.StringFlag{
+			Name:  "status-file",
+			Usage: "writes the status of the last run of --every to the given file as JSON, for a shell prompt to read",
+			Value: watch.DefaultStatusPath(),
+		},
+	}
+}
+
+// watchRuns runs the given function every interval until lopper is interrupted, which quits the current run, or
+// terminated, which lets the current run finish. Runs that fall in the quiet window are postponed until the window
+// ends. Every run is recorded in the history file like any other run, and the status of the last run is written to the
+// status file.
+func watchRuns(ctx *cli.Context, run func(ctx context.Context) (*ui.Model, error)) error {
+	every := ctx.Duration("every")
+	if every <= 0 {
+		return fmt.Errorf("--every must be a positive duration")
+	}
+	var quiet watch.Window
+	if ctx.IsSet("quiet-hours") {
+		var err error
+		if quiet, err = watch.ParseWindow(ctx.String("quiet-hours")); err != nil {
+			return err
+		}
+	}
+	watchCtx, stop := signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	for {
+		if !sleep(watchCtx, quiet.Wait(time.Now())) {
+			return nil
+		}
+		status := watch.Status{State: watch.OK, StartTime: time.Now()}
+		m, err := run(watchCtx)
+		status.EndTime = time.Now()
+		// the next run is scheduled from the start of this one, so the runs do not drift
+		status.NextRun = status.StartTime.Add(every)
+		status.NextRun = status.NextRun.Add(quiet.Wait(status.NextRun))
+		if err != nil {
+			// the next run may succeed (e.g. once a network drive is mounted again), so keep watching
+			fmt.Fprintln(os.Stderr, err)
+			status.State = watch.Error
+			status.Error = err.Error()
+		} else {
+			result := m.Result()
+			status.Deleted = result.Branches
+			status.Failed = result.Failed
+			if result.Failed > 0 {
+				status.State = watch.Failed
+			}
+		}
+		if path := ctx.String("status-file"); len(path) > 0 {
+			if err = watch.WriteStatus(path, status); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to write status: %s\n", err)
+			}
+		}
+		if !sleep(watchCtx, time.Until(status.NextRun)) {
+			return nil
+		}
+	}
+}
+
+// sleep waits for the given duration. False is returned when the given context is done before the duration has
+// elapsed.
+func sleep(ctx context.Context, duration time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if duration <= 0 {
+		return true
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
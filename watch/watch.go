@@ -0,0 +1,131 @@
+// Package watch schedules the runs of the watch mode and records their outcome.
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Window is a daily window of time (e.g. the night) in which no runs are started. The zero value is an empty window.
+type Window struct {
+	// start and end are the offsets of the window from midnight. The window wraps around midnight when end is before
+	// start.
+	start time.Duration
+	end   time.Duration
+}
+
+// ParseWindow parses a window in the form of start-end in 24-hour local time (e.g. 22:00-07:00).
+func ParseWindow(window string) (Window, error) {
+	startText, endText, ok := strings.Cut(window, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("invalid window %s, must be in the form of 22:00-07:00", window)
+	}
+	start, err := parseTimeOfDay(startText)
+	if err != nil {
+		return Window{}, err
+	}
+	end, err := parseTimeOfDay(endText)
+	if err != nil {
+		return Window{}, err
+	}
+	if start == end {
+		return Window{}, fmt.Errorf("invalid window %s, must not start and end at the same time", window)
+	}
+	return Window{start: start, end: end}, nil
+}
+
+func parseTimeOfDay(text string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(text))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %s, must be in the form of 22:00", text)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Wait returns how long to wait from the given time until the window ends. Zero is returned when the given time is
+// outside the window.
+func (w Window) Wait(t time.Time) time.Duration {
+	if w.start == w.end {
+		return 0
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	day := 24 * time.Hour
+	switch {
+	case w.start < w.end && offset >= w.start && offset < w.end:
+		return w.end - offset
+	case w.start > w.end && offset >= w.start:
+		return day - offset + w.end
+	case w.start > w.end && offset < w.end:
+		return w.end - offset
+	default:
+		return 0
+	}
+}
+
+// State is the outcome of a run.
+type State string
+
+const (
+	// OK is the State of a run that processed every repository.
+	OK State = "ok"
+	// Failed is the State of a run that failed to process some repositories.
+	Failed State = "failed"
+	// Error is the State of a run that could not run at all (e.g. the path does not exist).
+	Error State = "error"
+)
+
+// Status is the status of the last run of the watch mode. It is written as JSON, so a shell prompt can read it (e.g.
+// with jq).
+type Status struct {
+	State     State     `json:"state"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	// Deleted is the number of branches deleted, or that would be deleted on a dry run.
+	Deleted int `json:"deleted"`
+	// Failed is the number of repositories that failed to be processed.
+	Failed int    `json:"failed"`
+	Error  string `json:"error,omitempty"`
+	// NextRun is the time the next run is scheduled for.
+	NextRun time.Time `json:"nextRun"`
+}
+
+// WriteStatus writes the given Status to the file at the given path. The file is replaced atomically, so readers never
+// see a partially written status.
+func WriteStatus(path string, status Status) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = temp.Write(append(data, '\n')); err != nil {
+		_ = temp.Close()
+		_ = os.Remove(temp.Name())
+		return err
+	}
+	if err = temp.Close(); err != nil {
+		_ = os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// DefaultStatusPath returns the default path of the status file. An empty string is returned when the cache directory
+// of the user cannot be determined.
+func DefaultStatusPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "lopper", "status.json")
+}
@@ -0,0 +1,95 @@
+package watch
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   string
+		time     string
+		expected time.Duration
+	}{
+		{
+			name:     "Inside",
+			window:   "12:00-13:30",
+			time:     "12:30",
+			expected: time.Hour,
+		},
+		{
+			name:     "Before",
+			window:   "12:00-13:30",
+			time:     "11:59",
+			expected: 0,
+		},
+		{
+			name:     "End",
+			window:   "12:00-13:30",
+			time:     "13:30",
+			expected: 0,
+		},
+		{
+			name:     "Wrapped Before Midnight",
+			window:   "22:00-07:00",
+			time:     "23:00",
+			expected: 8 * time.Hour,
+		},
+		{
+			name:     "Wrapped After Midnight",
+			window:   "22:00-07:00",
+			time:     "06:15",
+			expected: 45 * time.Minute,
+		},
+		{
+			name:     "Wrapped Outside",
+			window:   "22:00-07:00",
+			time:     "12:00",
+			expected: 0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			window, err := ParseWindow(test.window)
+			require.NoError(t, err)
+			at, err := time.ParseInLocation("2006-01-02 15:04", "2022-10-01 "+test.time, time.Local)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, window.Wait(at))
+		})
+	}
+}
+
+func TestParseWindowInvalid(t *testing.T) {
+	for _, window := range []string{"22:00", "22:00-25:00", "noon-07:00", "07:00-07:00"} {
+		_, err := ParseWindow(window)
+		assert.Error(t, err, window)
+	}
+}
+
+func TestWriteStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lopper", "status.json")
+	status := Status{
+		State:     Failed,
+		StartTime: time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2022, 10, 1, 0, 1, 0, 0, time.UTC),
+		Deleted:   3,
+		Failed:    1,
+		NextRun:   time.Date(2022, 10, 2, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, WriteStatus(path, status))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var actual Status
+	require.NoError(t, json.Unmarshal(data, &actual))
+	assert.Equal(t, status, actual)
+	// the temporary file has been renamed
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}